@@ -0,0 +1,294 @@
+package authprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/nuclei/v3/pkg/authprovider/authx"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	urlutil "github.com/projectdiscovery/utils/url"
+)
+
+const (
+	// DefaultAPICacheSize is the default size of the in-memory LRU cache
+	DefaultAPICacheSize = 1024
+	// DefaultAPICacheTTL is the default ttl for a resolved entry
+	DefaultAPICacheTTL = 15 * time.Minute
+	// DefaultAPINegativeCacheTTL is the default ttl for a host with no secret
+	DefaultAPINegativeCacheTTL = 1 * time.Minute
+	// DefaultAPIRefreshBefore is how long before expiry the background refresher re-resolves an entry
+	DefaultAPIRefreshBefore = 30 * time.Second
+	// DefaultAPIMaxRetries is the default number of retries for the upstream lookup call
+	DefaultAPIMaxRetries = 2
+)
+
+// APIAuthProviderOptions contains configuration for the API backed auth provider
+type APIAuthProviderOptions struct {
+	// BaseURL is the base url of the auth service (eg. https://vault.example.com)
+	BaseURL string
+	// BearerToken is used to authenticate to the upstream auth service
+	BearerToken string
+	// CacheSize is the number of entries to keep in the in-memory LRU cache
+	CacheSize int
+	// TTL is the duration a positive (resolved) entry is considered fresh
+	TTL time.Duration
+	// NegativeTTL is the duration a negative (not-found) entry is cached for
+	NegativeTTL time.Duration
+	// MaxRetries is the number of retries performed after a failed upstream
+	// lookup (a transport error, a status other than 200/404, or an
+	// undecodable body)
+	MaxRetries int
+	// HTTPClient if set is used instead of the default http client
+	HTTPClient *http.Client
+}
+
+// apiCacheEntry holds a cached strategy alongside its expiry metadata
+type apiCacheEntry struct {
+	strategy authx.AuthStrategy
+	expiry   time.Time
+}
+
+func (e *apiCacheEntry) expired() bool {
+	return time.Now().After(e.expiry)
+}
+
+// APIAuthProviderMetrics exposes hit/miss counters for the API auth provider
+// so callers (eg. the nuclei runner) can surface them alongside scan stats.
+type APIAuthProviderMetrics struct {
+	Hits       uint64
+	Misses     uint64
+	Errors     uint64
+	Refreshes  uint64
+}
+
+// APIAuthProvider is an auth provider that resolves secrets for a host by
+// calling a remote HTTP(s) endpoint (eg. `GET /auth/lookup?host=example.com`).
+//
+// Resolved strategies are kept in an LRU cache with a TTL, concurrent lookups
+// for the same host are deduplicated, and a background goroutine refreshes
+// entries shortly before they expire so live traffic never blocks on the
+// upstream service.
+type APIAuthProvider struct {
+	options *APIAuthProviderOptions
+	client  *http.Client
+	cache   *lru.Cache[string, *apiCacheEntry]
+	group   singleflight.Group
+
+	metricsMu sync.Mutex
+	metrics   APIAuthProviderMetrics
+
+	closeOnce sync.Once
+	stopRefresher chan struct{}
+}
+
+// NewAPIAuthProvider creates a new API backed auth provider from options
+func NewAPIAuthProvider(options *APIAuthProviderOptions) (AuthProvider, error) {
+	if options.BaseURL == "" {
+		return nil, errorutil.New("base url is required for api auth provider")
+	}
+	if options.CacheSize <= 0 {
+		options.CacheSize = DefaultAPICacheSize
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultAPICacheTTL
+	}
+	if options.NegativeTTL <= 0 {
+		options.NegativeTTL = DefaultAPINegativeCacheTTL
+	}
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = DefaultAPIMaxRetries
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	cache, err := lru.New[string, *apiCacheEntry](options.CacheSize)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not create auth cache")
+	}
+
+	provider := &APIAuthProvider{
+		options:       options,
+		client:        options.HTTPClient,
+		cache:         cache,
+		stopRefresher: make(chan struct{}),
+	}
+	go provider.refreshLoop()
+	return provider, nil
+}
+
+// LookupAddr looks up a given domain/address and returns appropriate auth strategy
+func (a *APIAuthProvider) LookupAddr(addr string) authx.AuthStrategy {
+	if entry, ok := a.cache.Get(addr); ok && !entry.expired() {
+		a.recordHit()
+		return entry.strategy
+	}
+
+	v, err, _ := a.group.Do(addr, func() (interface{}, error) {
+		return a.resolve(addr)
+	})
+	if err != nil {
+		a.recordError()
+		gologger.Warning().Msgf("authprovider: could not resolve secret for %s: %s\n", addr, err)
+		return nil
+	}
+	a.recordMiss()
+	strategy, _ := v.(authx.AuthStrategy)
+	return strategy
+}
+
+// LookupURL looks up a given URL and returns appropriate auth strategy
+func (a *APIAuthProvider) LookupURL(u *url.URL) authx.AuthStrategy {
+	return a.LookupAddr(u.Host)
+}
+
+// LookupURLX looks up a given URL and returns appropriate auth strategy
+func (a *APIAuthProvider) LookupURLX(u *urlutil.URL) authx.AuthStrategy {
+	return a.LookupAddr(u.Host)
+}
+
+// Metrics returns a snapshot of the current hit/miss/error counters
+func (a *APIAuthProvider) Metrics() APIAuthProviderMetrics {
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	return a.metrics
+}
+
+// Close stops the background refresher goroutine
+func (a *APIAuthProvider) Close() {
+	a.closeOnce.Do(func() {
+		close(a.stopRefresher)
+	})
+}
+
+// resolve performs the actual HTTP lookup (with retries) against the upstream
+// auth service and stores the result (positive or negative) in the cache.
+func (a *APIAuthProvider) resolve(addr string) (authx.AuthStrategy, error) {
+	secret, err := a.fetchSecretWithRetry(addr)
+	if err != nil {
+		return nil, err
+	}
+	entry := &apiCacheEntry{expiry: time.Now().Add(a.options.NegativeTTL)}
+	if secret != nil {
+		if verr := secret.Validate(); verr != nil {
+			return nil, errorutil.NewWithErr(verr).Msgf("invalid secret returned for %s", addr)
+		}
+		entry.strategy = secret.GetStrategy()
+		entry.expiry = time.Now().Add(a.options.TTL)
+	}
+	a.cache.Add(addr, entry)
+	return entry.strategy, nil
+}
+
+func (a *APIAuthProvider) fetchSecretWithRetry(addr string) (*authx.Secret, error) {
+	var lastErr error
+	for attempt := 0; attempt <= a.options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDuration(attempt))
+		}
+		secret, err := a.fetchSecret(addr)
+		if err == nil {
+			return secret, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (a *APIAuthProvider) fetchSecret(addr string) (*authx.Secret, error) {
+	endpoint := strings.TrimSuffix(a.options.BaseURL, "/") + "/auth/lookup"
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := req.URL.Query()
+	query.Set("host", addr)
+	req.URL.RawQuery = query.Encode()
+	if a.options.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.options.BearerToken)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("auth lookup for %s returned status %d", addr, resp.StatusCode)
+	}
+
+	var secret authx.Secret
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not decode auth response for %s", addr)
+	}
+	return &secret, nil
+}
+
+// refreshLoop periodically walks the cache and re-resolves entries that are
+// about to expire so hot hosts never block live traffic on the upstream call.
+func (a *APIAuthProvider) refreshLoop() {
+	ticker := time.NewTicker(DefaultAPIRefreshBefore)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopRefresher:
+			return
+		case <-ticker.C:
+			for _, addr := range a.cache.Keys() {
+				entry, ok := a.cache.Peek(addr)
+				if !ok {
+					continue
+				}
+				if time.Until(entry.expiry) > DefaultAPIRefreshBefore {
+					continue
+				}
+				if _, err := a.resolve(addr); err == nil {
+					a.recordRefresh()
+				}
+			}
+		}
+	}
+}
+
+func backoffDuration(attempt int) time.Duration {
+	return time.Duration(attempt*attempt) * 200 * time.Millisecond
+}
+
+func (a *APIAuthProvider) recordHit() {
+	a.metricsMu.Lock()
+	a.metrics.Hits++
+	a.metricsMu.Unlock()
+}
+
+func (a *APIAuthProvider) recordMiss() {
+	a.metricsMu.Lock()
+	a.metrics.Misses++
+	a.metricsMu.Unlock()
+}
+
+func (a *APIAuthProvider) recordError() {
+	a.metricsMu.Lock()
+	a.metrics.Errors++
+	a.metricsMu.Unlock()
+}
+
+func (a *APIAuthProvider) recordRefresh() {
+	a.metricsMu.Lock()
+	a.metrics.Refreshes++
+	a.metricsMu.Unlock()
+}
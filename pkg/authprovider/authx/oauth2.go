@@ -0,0 +1,240 @@
+package authx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// OAuth2SecretType is the `type:` discriminator used in a secrets file entry
+// to select the IndieAuth/OAuth2 authorization-code strategy.
+const OAuth2SecretType = "oauth2"
+
+// oauth2RefreshBefore is how long before expiry a token is proactively refreshed.
+const oauth2RefreshBefore = 30 * time.Second
+
+// OAuth2Secret holds the static configuration for an IndieAuth / OAuth 2.0
+// authorization-code (with PKCE) secret, as declared in the auth secrets file:
+//
+//   - type: oauth2
+//     authorization_endpoint: https://indieauth.example.com/auth
+//     token_endpoint: https://indieauth.example.com/token
+//     client_id: https://client.example.com/
+//     scope: profile
+//     redirect_uri: https://client.example.com/callback
+//     authorization_code: "..." # or refresh_token below
+//     code_verifier: "..."      # required if the /authorize step sent a code_challenge
+//     refresh_token: "..."
+//
+// authorization_code/code_verifier/redirect_uri must match whatever the
+// /authorize redirect (performed out-of-band, before this secret is used)
+// actually sent: code_verifier is the PKCE verifier the challenge at
+// /authorize was derived from, not something generated fresh here - a token
+// endpoint enforcing PKCE validates the verifier against that original
+// challenge, so minting a new one at exchange time would never match.
+type OAuth2Secret struct {
+	AuthorizationEndpoint string `yaml:"authorization_endpoint" json:"authorization_endpoint"`
+	TokenEndpoint         string `yaml:"token_endpoint" json:"token_endpoint"`
+	ClientID              string `yaml:"client_id" json:"client_id"`
+	Scope                 string `yaml:"scope" json:"scope"`
+	RedirectURI           string `yaml:"redirect_uri" json:"redirect_uri"`
+	AuthorizationCode     string `yaml:"authorization_code" json:"authorization_code"`
+	CodeVerifier          string `yaml:"code_verifier" json:"code_verifier"`
+	RefreshToken          string `yaml:"refresh_token" json:"refresh_token"`
+}
+
+// Validate ensures the oauth2 secret has enough information to perform at
+// least one of the authorization-code or refresh-token grants.
+func (o *OAuth2Secret) Validate() error {
+	if o.TokenEndpoint == "" {
+		return errorutil.New("token_endpoint is required for oauth2 secret")
+	}
+	if o.ClientID == "" {
+		return errorutil.New("client_id is required for oauth2 secret")
+	}
+	if o.AuthorizationCode == "" && o.RefreshToken == "" {
+		return errorutil.New("either authorization_code or refresh_token is required for oauth2 secret")
+	}
+	return nil
+}
+
+// oauth2Token is the token endpoint response we care about.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Me           string `json:"me"`
+	Scope        string `json:"scope"`
+
+	expiresAt time.Time
+}
+
+func (t *oauth2Token) expired() bool {
+	return t.expiresAt.IsZero() || time.Now().After(t.expiresAt.Add(-oauth2RefreshBefore))
+}
+
+// OAuth2AuthStrategy implements AuthStrategy for the IndieAuth/OAuth2
+// authorization-code (with PKCE) flow. The access token is obtained (or
+// refreshed) on first use and cached until it is close to expiry. A
+// background goroutine proactively refreshes the token ~30s before expiry
+// (oauth2RefreshBefore) so the first request after expiry doesn't pay the
+// full refresh latency, with a per-strategy mutex guarding every refresh -
+// background or on-demand - so concurrent nuclei workers don't stampede the
+// token endpoint.
+type OAuth2AuthStrategy struct {
+	secret *OAuth2Secret
+	client *http.Client
+
+	mu    sync.Mutex
+	token *oauth2Token
+	timer *time.Timer
+}
+
+var _ AuthStrategy = &OAuth2AuthStrategy{}
+
+// NewOAuth2AuthStrategy creates an AuthStrategy that authenticates requests
+// using an IndieAuth/OAuth2 authorization-code (or refresh-token) grant.
+func NewOAuth2AuthStrategy(secret *OAuth2Secret) *OAuth2AuthStrategy {
+	return &OAuth2AuthStrategy{
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Apply injects a valid `Authorization: Bearer ...` header into the request,
+// obtaining or refreshing the access token as needed.
+func (o *OAuth2AuthStrategy) Apply(req *http.Request) {
+	token, err := o.getToken()
+	if err != nil {
+		// best-effort: leave the request unauthenticated rather than failing
+		// the scan outright, mirroring the other static strategies.
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+func (o *OAuth2AuthStrategy) getToken() (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != nil && !o.token.expired() {
+		return o.token.AccessToken, nil
+	}
+
+	return o.refreshLocked()
+}
+
+// refreshLocked performs the token-endpoint call and schedules the next
+// background refresh. Callers must hold o.mu.
+func (o *OAuth2AuthStrategy) refreshLocked() (string, error) {
+	token, err := o.exchangeOrRefresh()
+	if err != nil {
+		return "", err
+	}
+	o.token = token
+	o.scheduleBackgroundRefreshLocked()
+	return token.AccessToken, nil
+}
+
+// scheduleBackgroundRefreshLocked arms a timer to proactively refresh o.token
+// oauth2RefreshBefore ahead of its expiry, so getToken doesn't have to pay
+// the refresh latency on the first request made after expiry. Callers must
+// hold o.mu.
+func (o *OAuth2AuthStrategy) scheduleBackgroundRefreshLocked() {
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	if o.token == nil || o.token.expiresAt.IsZero() {
+		return
+	}
+	delay := time.Until(o.token.expiresAt.Add(-oauth2RefreshBefore))
+	if delay <= 0 {
+		return
+	}
+	o.timer = time.AfterFunc(delay, o.backgroundRefresh)
+}
+
+// backgroundRefresh is invoked by the timer armed in
+// scheduleBackgroundRefreshLocked. It re-checks expiry under the lock before
+// refreshing, since getToken may have already refreshed (and rescheduled)
+// the token in the meantime.
+func (o *OAuth2AuthStrategy) backgroundRefresh() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != nil && !o.token.expired() {
+		return
+	}
+	_, _ = o.refreshLocked()
+}
+
+// exchangeOrRefresh performs the token-endpoint call: a refresh-token grant
+// if we already have one (from config or a previous exchange), otherwise an
+// authorization-code + PKCE grant using the stored authorization code.
+func (o *OAuth2AuthStrategy) exchangeOrRefresh() (*oauth2Token, error) {
+	form := url.Values{}
+	form.Set("client_id", o.secret.ClientID)
+
+	refreshToken := o.secret.RefreshToken
+	if o.token != nil && o.token.RefreshToken != "" {
+		refreshToken = o.token.RefreshToken
+	}
+
+	switch {
+	case refreshToken != "":
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	case o.secret.AuthorizationCode != "":
+		form.Set("grant_type", "authorization_code")
+		form.Set("code", o.secret.AuthorizationCode)
+		if o.secret.RedirectURI != "" {
+			form.Set("redirect_uri", o.secret.RedirectURI)
+		}
+		if o.secret.CodeVerifier != "" {
+			form.Set("code_verifier", o.secret.CodeVerifier)
+		}
+		if o.secret.Scope != "" {
+			form.Set("scope", o.secret.Scope)
+		}
+	default:
+		return nil, errorutil.New("no refresh_token or authorization_code available for oauth2 secret")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.secret.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("token endpoint %s returned status %d: %s", o.secret.TokenEndpoint, resp.StatusCode, string(body))
+	}
+
+	var token oauth2Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not decode token response")
+	}
+	if token.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return &token, nil
+}
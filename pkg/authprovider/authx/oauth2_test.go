@@ -0,0 +1,55 @@
+package authx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestOAuth2ExchangeAuthorizationCodeSendsVerifierNotChallenge guards against
+// regressing to minting a fresh PKCE challenge at token-exchange time: the
+// token endpoint should only ever see the stored code_verifier (and
+// redirect_uri), never code_challenge/code_challenge_method.
+func TestOAuth2ExchangeAuthorizationCodeSendsVerifierNotChallenge(t *testing.T) {
+	var gotForm url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("could not parse form: %s", err)
+		}
+		gotForm = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token123","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	secret := &OAuth2Secret{
+		TokenEndpoint:     server.URL,
+		ClientID:          "https://client.example.com/",
+		RedirectURI:       "https://client.example.com/callback",
+		AuthorizationCode: "the-code",
+		CodeVerifier:      "the-verifier",
+	}
+	strategy := NewOAuth2AuthStrategy(secret)
+
+	token, err := strategy.exchangeOrRefresh()
+	if err != nil {
+		t.Fatalf("exchangeOrRefresh() error = %s", err)
+	}
+	if token.AccessToken != "token123" {
+		t.Errorf("AccessToken = %q, want %q", token.AccessToken, "token123")
+	}
+
+	if got := gotForm.Get("code_verifier"); got != "the-verifier" {
+		t.Errorf("code_verifier = %q, want %q", got, "the-verifier")
+	}
+	if got := gotForm.Get("redirect_uri"); got != secret.RedirectURI {
+		t.Errorf("redirect_uri = %q, want %q", got, secret.RedirectURI)
+	}
+	if gotForm.Has("code_challenge") {
+		t.Errorf("code_challenge should never be sent at token-exchange time, got %q", gotForm.Get("code_challenge"))
+	}
+	if gotForm.Has("code_challenge_method") {
+		t.Errorf("code_challenge_method should never be sent at token-exchange time, got %q", gotForm.Get("code_challenge_method"))
+	}
+}
@@ -0,0 +1,46 @@
+package authprovider
+
+import (
+	"net/url"
+
+	"github.com/projectdiscovery/nuclei/v3/pkg/authprovider/authx"
+	urlutil "github.com/projectdiscovery/utils/url"
+)
+
+// MultiAuthProvider chains multiple auth providers together and returns the
+// first non-nil strategy found, querying providers in the order they were
+// given. This allows users to migrate incrementally between providers (eg.
+// a file based provider backed by an API provider) without having to choose
+// one or the other.
+type MultiAuthProvider struct {
+	providers []AuthProvider
+}
+
+// NewMultiAuthProvider creates an auth provider that chains the given
+// providers together, querying them in order and returning the first match.
+func NewMultiAuthProvider(providers ...AuthProvider) AuthProvider {
+	return &MultiAuthProvider{providers: providers}
+}
+
+// LookupAddr looks up a given domain/address in all providers, in order
+func (m *MultiAuthProvider) LookupAddr(addr string) authx.AuthStrategy {
+	for _, provider := range m.providers {
+		if provider == nil {
+			continue
+		}
+		if strategy := provider.LookupAddr(addr); strategy != nil {
+			return strategy
+		}
+	}
+	return nil
+}
+
+// LookupURL looks up a given URL in all providers, in order
+func (m *MultiAuthProvider) LookupURL(u *url.URL) authx.AuthStrategy {
+	return m.LookupAddr(u.Host)
+}
+
+// LookupURLX looks up a given URL in all providers, in order
+func (m *MultiAuthProvider) LookupURLX(u *urlutil.URL) authx.AuthStrategy {
+	return m.LookupAddr(u.Host)
+}
@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/projectdiscovery/nuclei/v3/pkg/output"
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols"
+	"github.com/projectdiscovery/retryabledns"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// supported values for the template-level `transport:` field
+const (
+	TransportUDP   = "udp"
+	TransportTCP   = "tcp"
+	TransportTLS   = "tls"
+	TransportHTTPS = "https"
+)
+
+// getDnsClient builds a new retryabledns client used to dispatch
+// requests for this template. Resolvers may be either bare host[:port]
+// entries (plain UDP/TCP, governed by the template's `transport:` field) or
+// scheme-prefixed URLs (`https://1.1.1.1/dns-query`, `tls://8.8.8.8:853`)
+// which retryabledns resolves to DoH/DoT clients directly - in that case the
+// scheme on the resolver always wins over the template-level transport.
+func (request *Request) getDnsClient(options *protocols.ExecutorOptions, metadata output.InternalEvent) (*retryabledns.Client, error) {
+	resolvers := request.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = options.Options.Resolvers
+	}
+
+	normalized := make([]string, 0, len(resolvers))
+	for _, resolver := range resolvers {
+		normalized = append(normalized, request.normalizeResolver(resolver))
+	}
+	if err := validateResolvers(normalized); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid resolvers for %s", request.options.TemplateID)
+	}
+
+	retries := request.Retries
+	if retries == 0 {
+		retries = 2
+	}
+
+	dnsClientOptions := retryabledns.Options{
+		BaseResolvers: normalized,
+		MaxRetries:    retries,
+		ProxyURL:      options.Options.ProxyURL,
+	}
+	client, err := retryabledns.NewWithOptions(dnsClientOptions)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not create dns client")
+	}
+	return client, nil
+}
+
+// normalizeResolver prefixes a bare resolver with the scheme implied by the
+// template's `transport:` field, unless the resolver already carries its own
+// scheme (in which case it is left untouched).
+func (request *Request) normalizeResolver(resolver string) string {
+	if strings.Contains(resolver, "://") {
+		return resolver
+	}
+	switch request.Transport {
+	case TransportTLS:
+		return "tls://" + resolver
+	case TransportHTTPS:
+		return "https://" + resolver
+	case TransportTCP:
+		return "tcp://" + resolver
+	default:
+		return resolver
+	}
+}
+
+// validateResolvers ensures every scheme-prefixed resolver uses a transport
+// we know how to dial.
+func validateResolvers(resolvers []string) error {
+	for _, resolver := range resolvers {
+		if !strings.Contains(resolver, "://") {
+			continue
+		}
+		scheme := resolver[:strings.Index(resolver, "://")]
+		switch scheme {
+		case TransportTCP, TransportTLS, TransportHTTPS, TransportUDP:
+		default:
+			return errorutil.New("unsupported dns transport scheme %q in resolver %q", scheme, resolver)
+		}
+	}
+	return nil
+}
+
+// resolverTransport returns the transport that answered for a given resolver
+// string, used to populate the `dns_transport` matcher variable.
+func resolverTransport(resolver string) string {
+	if idx := strings.Index(resolver, "://"); idx != -1 {
+		return resolver[:idx]
+	}
+	return TransportUDP
+}
+
+// doWithResolverTracking dispatches msg and reports which of request's
+// configured resolvers actually answered. With at most one resolver it sends
+// msg through dnsClient. With several, dnsClient is not used: it is a single
+// combined retryabledns.Client spanning every configured resolver and
+// doesn't report which one of them produced the response, so each resolver
+// is tried in order with its own one-resolver client and the first to
+// answer is reported, instead of assuming Resolvers[0] always answers - that
+// assumption breaks as soon as a DoH/DoT fallback chain is in play.
+func (request *Request) doWithResolverTracking(dnsClient *retryabledns.Client, msg *dns.Msg) (*dns.Msg, string, error) {
+	resolvers := request.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = request.options.Options.Resolvers
+	}
+	if len(resolvers) <= 1 {
+		response, err := dnsClient.Do(msg)
+		answering := ""
+		if len(resolvers) == 1 {
+			answering = request.normalizeResolver(resolvers[0])
+		}
+		return response, answering, err
+	}
+
+	retries := request.Retries
+	if retries == 0 {
+		retries = 2
+	}
+
+	var lastErr error
+	for _, resolver := range resolvers {
+		normalized := request.normalizeResolver(resolver)
+		single, err := retryabledns.NewWithOptions(retryabledns.Options{
+			BaseResolvers: []string{normalized},
+			MaxRetries:    retries,
+			ProxyURL:      request.options.Options.ProxyURL,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		response, err := single.Do(msg)
+		if err == nil && response != nil {
+			return response, normalized, nil
+		}
+		lastErr = err
+	}
+	return nil, "", lastErr
+}
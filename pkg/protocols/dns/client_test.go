@@ -0,0 +1,65 @@
+package dns
+
+import "testing"
+
+func TestNormalizeResolver(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport string
+		resolver  string
+		want      string
+	}{
+		{"bare resolver, no transport", "", "8.8.8.8", "8.8.8.8"},
+		{"bare resolver, tcp transport", TransportTCP, "8.8.8.8", "tcp://8.8.8.8"},
+		{"bare resolver, tls transport", TransportTLS, "8.8.8.8:853", "tls://8.8.8.8:853"},
+		{"bare resolver, https transport", TransportHTTPS, "1.1.1.1", "https://1.1.1.1"},
+		{"scheme already present wins over transport", TransportTCP, "https://1.1.1.1/dns-query", "https://1.1.1.1/dns-query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &Request{Transport: tt.transport}
+			if got := request.normalizeResolver(tt.resolver); got != tt.want {
+				t.Errorf("normalizeResolver(%q) with transport %q = %q, want %q", tt.resolver, tt.transport, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateResolvers(t *testing.T) {
+	tests := []struct {
+		name      string
+		resolvers []string
+		wantErr   bool
+	}{
+		{"no resolvers", nil, false},
+		{"bare resolvers", []string{"8.8.8.8", "1.1.1.1:53"}, false},
+		{"supported schemes", []string{"tcp://8.8.8.8", "tls://1.1.1.1:853", "https://1.1.1.1/dns-query"}, false},
+		{"unsupported scheme", []string{"ftp://8.8.8.8"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResolvers(tt.resolvers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateResolvers(%v) error = %v, wantErr %v", tt.resolvers, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolverTransport(t *testing.T) {
+	tests := []struct {
+		resolver string
+		want     string
+	}{
+		{"8.8.8.8", TransportUDP},
+		{"tcp://8.8.8.8", TransportTCP},
+		{"tls://1.1.1.1:853", TransportTLS},
+		{"https://1.1.1.1/dns-query", TransportHTTPS},
+		{"", TransportUDP},
+	}
+	for _, tt := range tests {
+		if got := resolverTransport(tt.resolver); got != tt.want {
+			t.Errorf("resolverTransport(%q) = %q, want %q", tt.resolver, got, tt.want)
+		}
+	}
+}
@@ -182,8 +182,10 @@ func (request *Request) execute(input *contextargs.Context, domain string, metad
 
 	request.options.RateLimiter.Take()
 
-	// Send the request to the target servers
-	response, err := dnsClient.Do(compiledRequest)
+	// Send the request to the target servers, tracking which resolver in
+	// the fallback chain actually answered rather than assuming it was
+	// always the first configured one.
+	response, answeringResolver, err := request.doWithResolverTracking(dnsClient, compiledRequest)
 	if err != nil {
 		request.options.Output.Request(request.options.TemplatePath, domain, request.Type().String(), err)
 		request.options.Progress.IncrementFailedRequestsBy(1)
@@ -208,6 +210,10 @@ func (request *Request) execute(input *contextargs.Context, domain string, metad
 
 	// Create the output event
 	outputEvent := request.responseToDSLMap(compiledRequest, response, domain, question, traceData)
+	// expose which resolver/transport actually answered so templates can
+	// assert on DoH/DoT vs plain UDP/TCP channels
+	outputEvent["dns_transport"] = resolverTransport(answeringResolver)
+	outputEvent["dns_resolver_url"] = answeringResolver
 	// expose response variables in proto_var format
 	// this is no-op if the template is not a multi protocol template
 	request.options.AddTemplateVars(input.MetaInput, request.Type(), request.ID, outputEvent)
@@ -223,7 +229,7 @@ func (request *Request) execute(input *contextargs.Context, domain string, metad
 	}
 	event := eventcreator.CreateEvent(request, outputEvent, request.options.Options.Debug || request.options.Options.DebugResponse)
 
-	dumpResponse(event, request, response.String(), question)
+	dumpResponse(event, request, response.String(), question, outputEvent["dns_transport"].(string))
 	if request.Trace {
 		dumpTraceData(event, request.options, traceToString(traceData, true), question)
 	}
@@ -249,7 +255,7 @@ func (request *Request) parseDNSInput(host string) (string, error) {
 	return host, nil
 }
 
-func dumpResponse(event *output.InternalWrappedEvent, request *Request, response, domain string) {
+func dumpResponse(event *output.InternalWrappedEvent, request *Request, response, domain, transport string) {
 	cliOptions := request.options.Options
 	if cliOptions.Debug || cliOptions.DebugResponse || cliOptions.StoreResponse {
 		hexDump := false
@@ -258,7 +264,7 @@ func dumpResponse(event *output.InternalWrappedEvent, request *Request, response
 			response = hex.Dump([]byte(response))
 		}
 		highlightedResponse := responsehighlighter.Highlight(event.OperatorsResult, response, cliOptions.NoColor, hexDump)
-		msg := fmt.Sprintf("[%s] Dumped DNS response for %s\n\n%s", request.options.TemplateID, domain, highlightedResponse)
+		msg := fmt.Sprintf("[%s] Dumped DNS response for %s (transport: %s)\n\n%s", request.options.TemplateID, domain, transport, highlightedResponse)
 		if cliOptions.Debug || cliOptions.DebugResponse {
 			gologger.Debug().Msg(msg)
 		}
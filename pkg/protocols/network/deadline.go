@@ -0,0 +1,91 @@
+package network
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/utils/reader"
+)
+
+// deadlineTimer composes a net.Conn's read/write deadlines from a sequence of
+// per-step timeouts (eg. one Input's ReadTimeout/WriteTimeout), so that a
+// template that writes/reads N times can give each step its own deadline
+// instead of relying on a single connection-wide timeout. It is safe for
+// concurrent use; setting a new deadline while a previous one is still
+// pending simply resets it rather than leaking a stale timer.
+type deadlineTimer struct {
+	conn net.Conn
+
+	mu        sync.Mutex
+	readTimer *time.Timer
+}
+
+// newDeadlineTimer wraps conn so its read/write deadlines can be set per-step.
+func newDeadlineTimer(conn net.Conn) *deadlineTimer {
+	return &deadlineTimer{conn: conn}
+}
+
+// setReadDeadline resets the connection's read deadline to now+timeout,
+// falling back to DefaultReadTimeout when timeout is zero. Safe to call
+// repeatedly; it always supersedes the previous deadline rather than
+// stacking timers.
+func (d *deadlineTimer) setReadDeadline(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = DefaultReadTimeout
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// setWriteDeadline resets the connection's write deadline to now+timeout.
+func (d *deadlineTimer) setWriteDeadline(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = DefaultReadTimeout
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.conn.SetWriteDeadline(time.Now().Add(timeout))
+}
+
+// clear removes any pending deadline, letting subsequent I/O block
+// indefinitely (mirroring the existing `SetDeadline(time.Time{})` reset).
+func (d *deadlineTimer) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.conn.SetReadDeadline(time.Time{})
+	_ = d.conn.SetWriteDeadline(time.Time{})
+}
+
+// ConnReadNWithDeadline is the deadlineTimer-aware counterpart of
+// ConnReadNWithTimeout, used where a per-step timeout (Input.ReadTimeout)
+// must compose with the rest of the read pipeline (including ReadAll mode)
+// without leaking timers on early return.
+func ConnReadNWithDeadline(dt *deadlineTimer, n int64, timeout time.Duration) ([]byte, error) {
+	dt.setReadDeadline(timeout)
+	defer dt.clear()
+
+	if n == -1 {
+		return reader.ConnReadNWithTimeout(dt.conn, -1, timeoutOrDefault(timeout))
+	} else if n == 0 {
+		n = 4096
+	}
+	b := make([]byte, n)
+	count, err := dt.conn.Read(b)
+	if err != nil && os.IsTimeout(err) && count > 0 {
+		return b[:count], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b[:count], nil
+}
+
+func timeoutOrDefault(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return DefaultReadTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,148 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sort"
+	"time"
+)
+
+// DefaultHappyEyeballsStagger is the default delay between launching
+// successive connection attempts when racing resolved addresses.
+const DefaultHappyEyeballsStagger = 250 * time.Millisecond
+
+// happyEyeballsEnabled reports whether Happy-Eyeballs dual-stack dialing is
+// active for this request, either opted into per-template or globally.
+func (request *Request) happyEyeballsEnabled() bool {
+	if request.HappyEyeballs {
+		return true
+	}
+	return request.options.Options.HappyEyeballs
+}
+
+// happyEyeballsResult captures the outcome of the winning connection attempt
+// so it can be surfaced on the output event.
+type happyEyeballsResult struct {
+	conn          net.Conn
+	ip            string
+	family        string
+	connectTimeMs int64
+}
+
+// happyEyeballsAttempt is one racer's outcome on dialHappyEyeballs's results
+// channel: either a successful happyEyeballsResult or the error that racer
+// failed with.
+type happyEyeballsAttempt struct {
+	res *happyEyeballsResult
+	err error
+}
+
+// dialHappyEyeballs resolves hostname to its full set of A/AAAA addresses,
+// orders them per a simplified RFC 6724 destination-address-selection
+// policy, and races connections with a small stagger between attempts,
+// returning the first that succeeds and cancelling the rest.
+func (request *Request) dialHappyEyeballs(ctx context.Context, hostname, port string, shouldUseTLS bool) (*happyEyeballsResult, error) {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	sortRFC6724(ips)
+
+	stagger := request.HappyEyeballsStagger
+	if stagger <= 0 {
+		stagger = DefaultHappyEyeballsStagger
+	}
+
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan happyEyeballsAttempt, len(ips))
+	for i, ip := range ips {
+		delay := time.Duration(i) * stagger
+		go func(ip net.IPAddr, delay time.Duration) {
+			select {
+			case <-raceCtx.Done():
+				results <- happyEyeballsAttempt{err: raceCtx.Err()}
+				return
+			case <-time.After(delay):
+			}
+
+			start := time.Now()
+			addr := net.JoinHostPort(ip.String(), port)
+			conn, dialErr := request.dial(raceCtx, addr, shouldUseTLS)
+			if dialErr != nil {
+				results <- happyEyeballsAttempt{err: dialErr}
+				return
+			}
+			results <- happyEyeballsAttempt{res: &happyEyeballsResult{
+				conn:          conn,
+				ip:            ip.String(),
+				family:        addressFamily(ip.IP),
+				connectTimeMs: time.Since(start).Milliseconds(),
+			}}
+		}(ip, delay)
+	}
+
+	var lastErr error
+	consumed := 0
+	for ; consumed < len(ips); consumed++ {
+		r := <-results
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		cancel() // stop the remaining, slower attempts
+		// other racers (eg. the other address family on a dual-stack host)
+		// may already be mid-dial and still succeed after we've picked a
+		// winner; drain them in the background and close their sockets
+		// instead of leaking them for the life of the process.
+		if remaining := len(ips) - consumed - 1; remaining > 0 {
+			go drainLosingAttempts(results, remaining)
+		}
+		return r.res, nil
+	}
+	if lastErr == nil {
+		lastErr = net.UnknownNetworkError("happy-eyeballs: no addresses resolved")
+	}
+	return nil, lastErr
+}
+
+// drainLosingAttempts reads exactly n more results off results, closing the
+// connection of any attempt that succeeded after the race was already won.
+func drainLosingAttempts(results <-chan happyEyeballsAttempt, n int) {
+	for i := 0; i < n; i++ {
+		r := <-results
+		if r.res != nil && r.res.conn != nil {
+			r.res.conn.Close()
+		}
+	}
+}
+
+// sortRFC6724 orders addresses per a simplified version of RFC 6724's
+// destination address selection: loopback and link-local addresses are
+// moved behind routable ones, and routable IPv4 addresses are placed ahead
+// of routable IPv6 ones. Within each group the input order is kept; no
+// longest-common-prefix comparison with a local address is made, and
+// deprecated/temporary addresses are not recognised.
+func sortRFC6724(ips []net.IPAddr) {
+	precedence := func(ip net.IP) int {
+		switch {
+		case ip.IsLoopback(), ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+			return 0
+		case ip.To4() != nil:
+			return 2 // prefer IPv4-mapped/global IPv4 slightly above generic global
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(ips, func(i, j int) bool {
+		return precedence(ips[i].IP) > precedence(ips[j].IP)
+	})
+}
+
+func addressFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
@@ -0,0 +1,55 @@
+package network
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAddressFamily(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"93.184.216.34", "ipv4"},
+		{"2606:2800:220:1:248:1893:25c8:1946", "ipv6"},
+	}
+	for _, tt := range tests {
+		if got := addressFamily(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("addressFamily(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+// TestSortRFC6724PrefersGlobalOverLinkLocal guards against link-local/loopback
+// addresses being raced ahead of routable ones.
+func TestSortRFC6724PrefersGlobalOverLinkLocal(t *testing.T) {
+	ips := []net.IPAddr{
+		{IP: net.ParseIP("169.254.1.1")}, // link-local
+		{IP: net.ParseIP("93.184.216.34")},
+		{IP: net.ParseIP("127.0.0.1")}, // loopback
+	}
+	sortRFC6724(ips)
+
+	if got := ips[0].IP.String(); got != "93.184.216.34" {
+		t.Errorf("first address after sort = %s, want global unicast 93.184.216.34", got)
+	}
+}
+
+// TestDrainLosingAttemptsClosesSuccessfulLosers ensures that a racer which
+// succeeds after the race has already been won still gets its connection
+// closed instead of leaked.
+func TestDrainLosingAttemptsClosesSuccessfulLosers(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	results := make(chan happyEyeballsAttempt, 2)
+	results <- happyEyeballsAttempt{res: &happyEyeballsResult{conn: client}}
+	results <- happyEyeballsAttempt{err: net.UnknownNetworkError("dial failed")}
+
+	drainLosingAttempts(results, 2)
+
+	// a closed net.Pipe conn returns io.ErrClosedPipe on further writes.
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Error("expected losing connection to be closed, but write succeeded")
+	}
+}
@@ -0,0 +1,161 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/contextargs"
+)
+
+// DefaultPortScanConcurrency bounds how many ports are probed concurrently
+// when neither the request's PortScanConcurrency nor the global
+// PayloadConcurrency option is set.
+const DefaultPortScanConcurrency = 25
+
+// DefaultPortScanDeadline bounds the total time spent discovering open ports
+// for a single target, regardless of how many ports the template lists.
+const DefaultPortScanDeadline = 30 * time.Second
+
+// PortProber discovers which of a set of ports are open on a target. The
+// default implementation performs a plain connect-scan using the shared
+// dialer, but callers may plug in a faster half-open/SYN prober, or one
+// backed by an already-cached external port-scan result (eg. naabu), by
+// assigning a PortProber to Request.prober before execution.
+type PortProber interface {
+	// Probe reports whether `port` is open on the host described by addr
+	// (host:port), honoring ctx for cancellation/deadline.
+	Probe(ctx context.Context, addr, port string) (bool, error)
+}
+
+// connectScanProber is the default PortProber: it dials the port with the
+// request's configured transport and considers it open if the dial (and, for
+// UDP, the initial write) succeeds.
+type connectScanProber struct {
+	request *Request
+}
+
+func (c *connectScanProber) Probe(ctx context.Context, addr, port string) (bool, error) {
+	conn, err := c.request.dial(ctx, addr, false)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if c.request.transportOrDefault() == TransportUDP || c.request.transportOrDefault() == TransportDTLS {
+		if _, err := conn.Write([]byte{}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// portProber returns the configured PortProber, falling back to a plain
+// connect-scan using the shared dialer.
+func (request *Request) portProber() PortProber {
+	if request.prober != nil {
+		return request.prober
+	}
+	return &connectScanProber{request: request}
+}
+
+// getOpenPorts streams open ports from the list of ports provided in the
+// template as they're discovered, instead of waiting for the full sweep to
+// complete - a template listing dozens of ports can start executing against
+// the first ones found while the rest are still being probed (bounded by
+// PortScanConcurrency, against a global deadline). If only one port is
+// provided, no probing is necessary and it's sent immediately.
+//
+// The returned ports channel is closed once every port has been probed (or
+// the deadline/context is hit); the returned error channel then yields a
+// single value - nil if at least one port was found open, otherwise the
+// combined probing errors - and is closed right after.
+func (request *Request) getOpenPorts(target *contextargs.Context) (<-chan string, <-chan error) {
+	portsCh := make(chan string)
+	errCh := make(chan error, 1)
+
+	if len(request.ports) == 1 {
+		go func() {
+			defer close(portsCh)
+			defer close(errCh)
+			portsCh <- request.ports[0]
+			errCh <- nil
+		}()
+		return portsCh, errCh
+	}
+
+	concurrency := request.options.Options.PayloadConcurrency
+	if request.PortScanConcurrency > 0 {
+		concurrency = request.PortScanConcurrency
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultPortScanConcurrency
+	}
+
+	go func() {
+		defer close(portsCh)
+		defer close(errCh)
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultPortScanDeadline)
+		defer cancel()
+
+		prober := request.portProber()
+
+		var (
+			mu        sync.Mutex
+			errs      []error
+			openCount int64
+		)
+
+		errGroup, gctx := errgroup.WithContext(ctx)
+		errGroup.SetLimit(concurrency)
+
+		for _, port := range request.ports {
+			port := port
+			errGroup.Go(func() error {
+				cloned := target.Clone()
+				if err := cloned.UseNetworkPort(port, request.ExcludePorts); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					return nil
+				}
+				addr, err := getAddress(cloned.MetaInput.Input)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					return nil
+				}
+
+				open, err := prober.Probe(gctx, addr, port)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					return nil
+				}
+				if open {
+					atomic.AddInt64(&openCount, 1)
+					// consume the prober's stream as ports become known
+					// rather than waiting for the full sweep to finish.
+					portsCh <- port
+				}
+				return nil
+			})
+		}
+		_ = errGroup.Wait()
+
+		if atomic.LoadInt64(&openCount) == 0 {
+			errCh <- multierr.Combine(errs...)
+			return
+		}
+		errCh <- nil
+	}()
+
+	return portsCh, errCh
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"net"
+	"os"
+	"regexp"
+	"time"
+)
+
+// DefaultMaxReadSize bounds a delimiter/regex terminated read when neither
+// ReadSize nor MaxReadSize are set on the template, so a server that never
+// sends the expected terminator can't make nuclei buffer unbounded data.
+const DefaultMaxReadSize = 1 << 20 // 1MB
+
+// readUntilTerminator incrementally reads from conn, resetting the read
+// deadline on every bit of progress, until either:
+//   - literal delim is found in the accumulated buffer, or
+//   - re matches the accumulated buffer, or
+//   - maxSize bytes have been read, or
+//   - the deadline is hit with no further progress
+//
+// It returns the data read so far (including the terminator, if matched) and
+// the matched terminator text, if any.
+func readUntilTerminator(conn net.Conn, delim []byte, re *regexp.Regexp, maxSize int64, timeout time.Duration) ([]byte, string, error) {
+	if timeout == 0 {
+		timeout = DefaultReadTimeout
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxReadSize
+	}
+
+	buf := make([]byte, 0, 4096)
+	chunk := make([]byte, 4096)
+
+	for int64(len(buf)) < maxSize {
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		n, err := conn.Read(chunk)
+		_ = conn.SetReadDeadline(time.Time{})
+
+		if n > 0 {
+			buf = append(buf, chunk[:n]...)
+
+			if len(delim) > 0 {
+				if idx := indexOf(buf, delim); idx != -1 {
+					end := idx + len(delim)
+					return buf[:end], string(delim), nil
+				}
+			}
+			if re != nil {
+				if loc := re.FindIndex(buf); loc != nil {
+					return buf[:loc[1]], string(buf[loc[0]:loc[1]]), nil
+				}
+			}
+		}
+
+		if err != nil {
+			if os.IsTimeout(err) && n > 0 {
+				// progress was made this round, but no terminator matched yet
+				// and the deadline was hit - keep trying until maxSize
+				continue
+			}
+			if os.IsTimeout(err) {
+				return buf, "", err
+			}
+			return buf, "", err
+		}
+	}
+	return buf, "", nil
+}
+
+func indexOf(haystack, needle []byte) int {
+	if len(needle) == 0 || len(needle) > len(haystack) {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
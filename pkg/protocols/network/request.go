@@ -26,7 +26,6 @@ import (
 	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/helpers/eventcreator"
 	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/helpers/responsehighlighter"
 	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/interactsh"
-	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/protocolstate"
 	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/replacer"
 	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/utils/vardump"
 	protocolutils "github.com/projectdiscovery/nuclei/v3/pkg/protocols/utils"
@@ -50,41 +49,6 @@ func (request *Request) Type() templateTypes.ProtocolType {
 	return templateTypes.NetworkProtocol
 }
 
-// getOpenPorts returns all open ports from list of ports provided in template
-// if only 1 port is provided, no need to check if port is open or not
-func (request *Request) getOpenPorts(target *contextargs.Context) ([]string, error) {
-	if len(request.ports) == 1 {
-		// no need to check if port is open or not
-		return request.ports, nil
-	}
-	errs := []error{}
-	// if more than 1 port is provided, check if port is open or not
-	openPorts := make([]string, 0)
-	for _, port := range request.ports {
-		cloned := target.Clone()
-		if err := cloned.UseNetworkPort(port, request.ExcludePorts); err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		addr, err := getAddress(cloned.MetaInput.Input)
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		conn, err := protocolstate.Dialer.Dial(context.TODO(), "tcp", addr)
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		_ = conn.Close()
-		openPorts = append(openPorts, port)
-	}
-	if len(openPorts) == 0 {
-		return nil, multierr.Combine(errs...)
-	}
-	return openPorts, nil
-}
-
 // ExecuteWithResults executes the protocol requests and returns results instead of writing them.
 func (request *Request) ExecuteWithResults(target *contextargs.Context, metadata, previous output.InternalEvent) <-chan protocols.Result {
 	results := make(chan protocols.Result)
@@ -109,17 +73,12 @@ func (request *Request) ExecuteWithResults(target *contextargs.Context, metadata
 			}
 		}
 
-		// get open ports from list of ports provided in template
-		ports, err := request.getOpenPorts(target)
-		if len(ports) == 0 {
-			return err
-		}
-		if err != nil {
-			// TODO: replace this after scan context is implemented
-			gologger.Verbose().Msgf("[%v] got errors while checking open ports: %s\n", request.options.TemplateID, err)
-		}
-
-		for _, port := range ports {
+		// stream open ports from list of ports provided in template, executing
+		// on each as it's discovered rather than waiting for the full sweep
+		portsCh, scanErrCh := request.getOpenPorts(target)
+		foundOpenPort := false
+		for port := range portsCh {
+			foundOpenPort = true
 			input := target.Clone()
 			// use network port updates input with new port requested in template file
 			// and it is ignored if input port is not standard http(s) ports like 80,8080,8081 etc
@@ -133,6 +92,14 @@ func (request *Request) ExecuteWithResults(target *contextargs.Context, metadata
 				return err
 			}
 		}
+		scanErr := <-scanErrCh
+		if !foundOpenPort {
+			return scanErr
+		}
+		if scanErr != nil {
+			// TODO: replace this after scan context is implemented
+			gologger.Verbose().Msgf("[%v] got errors while checking open ports: %s\n", request.options.TemplateID, scanErr)
+		}
 
 		return nil
 	})
@@ -263,10 +230,27 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 		hostname = host
 	}
 
-	if shouldUseTLS {
-		conn, err = request.dialer.DialTLS(context.Background(), "tcp", actualAddress)
-	} else {
-		conn, err = request.dialer.Dial(context.Background(), "tcp", actualAddress)
+	var (
+		happyEyeballsIP            string
+		happyEyeballsFamily        string
+		happyEyeballsConnectTimeMs int64
+	)
+	if hostname != "" && request.happyEyeballsEnabled() {
+		_, port, splitErr := net.SplitHostPort(actualAddress)
+		if splitErr == nil {
+			result, heErr := request.dialHappyEyeballs(context.Background(), hostname, port, shouldUseTLS)
+			if heErr == nil {
+				conn = result.conn
+				happyEyeballsIP = result.ip
+				happyEyeballsFamily = result.family
+				happyEyeballsConnectTimeMs = result.connectTimeMs
+			} else {
+				err = heErr
+			}
+		}
+	}
+	if conn == nil {
+		conn, err = request.dial(context.Background(), actualAddress, shouldUseTLS)
 	}
 	if err != nil {
 		request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), err)
@@ -274,7 +258,11 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 		return nil, errors.Wrap(err, "could not connect to server")
 	}
 	defer conn.Close()
-	_ = conn.SetDeadline(time.Now().Add(time.Duration(request.options.Options.Timeout) * time.Second))
+	idleTimeout := request.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = time.Duration(request.options.Options.Timeout) * time.Second
+	}
+	_ = conn.SetDeadline(time.Now().Add(idleTimeout))
 
 	var interactshURLs []string
 
@@ -287,8 +275,22 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 	}
 
 	inputEvents := make(map[string]interface{})
+	dt := newDeadlineTimer(conn)
 
-	for _, input := range request.Inputs {
+	var stepsTrace []string
+	usesSteps := len(request.Steps) > 0
+
+	if usesSteps {
+		var stepsErr error
+		stepsTrace, stepsErr = request.runSteps(conn, dt, interimValues, payloads, inputEvents, &reqBuilder, &responseBuilder)
+		if stepsErr != nil {
+			request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), stepsErr)
+			request.options.Progress.IncrementFailedRequestsBy(1)
+			return nil, errorutil.NewWithErr(stepsErr).Msgf("could not execute steps")
+		}
+	}
+
+	for _, input := range inputsUnlessSteps(usesSteps, request.Inputs) {
 		data := []byte(input.Data)
 
 		if request.options.Interactsh != nil {
@@ -320,14 +322,29 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 			}
 		}
 
-		if _, err := conn.Write(finalData); err != nil {
-			request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), err)
+		dt.setWriteDeadline(input.WriteTimeout)
+		_, writeErr := conn.Write(finalData)
+		dt.clear()
+		if writeErr != nil {
+			request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), writeErr)
 			request.options.Progress.IncrementFailedRequestsBy(1)
-			return nil, errors.Wrap(err, "could not write request to server")
+			return nil, errors.Wrap(writeErr, "could not write request to server")
 		}
 
-		if input.Read > 0 {
-			buffer, err := ConnReadNWithTimeout(conn, int64(input.Read), DefaultReadTimeout)
+		hasTerminator := len(input.ReadUntil) > 0 || input.ReadRegex != nil
+
+		if input.Read > 0 || hasTerminator {
+			var buffer []byte
+			var terminator string
+
+			switch {
+			case hasTerminator:
+				buffer, terminator, err = readUntilTerminator(conn, []byte(input.ReadUntil), input.ReadRegex, int64(input.Read), input.ReadTimeout)
+			case request.transportOrDefault() == TransportUDP || request.transportOrDefault() == TransportDTLS:
+				buffer, err = readDatagram(conn, int64(input.Read), input.ReadTimeout)
+			default:
+				buffer, err = ConnReadNWithDeadline(dt, int64(input.Read), input.ReadTimeout)
+			}
 			if err != nil {
 				return nil, errorutil.NewWithErr(err).Msgf("could not read response from connection")
 			}
@@ -338,6 +355,10 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 			if input.Name != "" {
 				inputEvents[input.Name] = bufferStr
 				interimValues[input.Name] = bufferStr
+				if hasTerminator {
+					inputEvents[input.Name+"_terminator"] = terminator
+					interimValues[input.Name+"_terminator"] = terminator
+				}
 			}
 
 			// Run any internal extractors for the request here and add found values to map.
@@ -369,29 +390,61 @@ func (request *Request) executeRequestWithPayloads(variables map[string]interfac
 	request.options.Output.Request(request.options.TemplatePath, actualAddress, request.Type().String(), err)
 	gologger.Verbose().Msgf("Sent TCP request to %s", actualAddress)
 
-	bufferSize := 1024
-	if request.ReadSize != 0 {
-		bufferSize = request.ReadSize
-	}
-	if request.ReadAll {
-		bufferSize = -1
-	}
+	var final []byte
+	if !usesSteps {
+		bufferSize := 1024
+		if request.ReadSize != 0 {
+			bufferSize = request.ReadSize
+		}
+		if request.ReadAll {
+			bufferSize = -1
+		}
 
-	final, err := ConnReadNWithTimeout(conn, int64(bufferSize), DefaultReadTimeout)
-	if err != nil {
-		request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), err)
-		gologger.Verbose().Msgf("could not read more data from %s: %s", actualAddress, err)
+		hasTailTerminator := len(request.ReadUntil) > 0 || request.ReadRegex != nil
+
+		switch {
+		case hasTailTerminator:
+			maxSize := int64(request.MaxReadSize)
+			if maxSize == 0 && !request.ReadAll {
+				// 0 (ReadSize unset) lets readUntilTerminator apply DefaultMaxReadSize
+				maxSize = int64(request.ReadSize)
+			}
+			final, _, err = readUntilTerminator(conn, []byte(request.ReadUntil), request.ReadRegex, maxSize, DefaultReadTimeout)
+		case request.transportOrDefault() == TransportUDP || request.transportOrDefault() == TransportDTLS:
+			if bufferSize == -1 {
+				// datagrams have no "read everything" concept - fall back to a single MTU-sized read
+				bufferSize = 65507
+			}
+			final, err = readDatagram(conn, int64(bufferSize), DefaultReadTimeout)
+		default:
+			final, err = ConnReadNWithTimeout(conn, int64(bufferSize), DefaultReadTimeout)
+		}
+		if err != nil {
+			request.options.Output.Request(request.options.TemplatePath, address, request.Type().String(), err)
+			gologger.Verbose().Msgf("could not read more data from %s: %s", actualAddress, err)
+		}
+		responseBuilder.Write(final)
 	}
-	responseBuilder.Write(final)
 
 	response := responseBuilder.String()
 	outputEvent := request.responseToDSLMap(reqBuilder.String(), string(final), response, input.MetaInput.Input, actualAddress)
+	if usesSteps {
+		outputEvent["steps_trace"] = stepsTrace
+	}
 	// add response fields to template context and merge templatectx variables to output event
 	request.options.AddTemplateVars(input.MetaInput, request.Type(), request.ID, outputEvent)
 	if request.options.HasTemplateCtx(input.MetaInput) {
 		outputEvent = generators.MergeMaps(outputEvent, request.options.GetTemplateCtx(input.MetaInput).GetAll())
 	}
-	outputEvent["ip"] = request.dialer.GetDialedIP(hostname)
+	if happyEyeballsIP != "" {
+		// the winning address came from the Happy-Eyeballs race, not the
+		// shared dialer, so report it directly rather than via GetDialedIP
+		outputEvent["ip"] = happyEyeballsIP
+		outputEvent["ip_family"] = happyEyeballsFamily
+		outputEvent["connect_time_ms"] = happyEyeballsConnectTimeMs
+	} else {
+		outputEvent["ip"] = request.dialer.GetDialedIP(hostname)
+	}
 	if request.options.StopAtFirstMatch {
 		outputEvent["stop-at-first-match"] = true
 	}
@@ -0,0 +1,268 @@
+package network
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/expressions"
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/generators"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// Step is a single entry in a Request's `Steps` conversation script. Unlike
+// the legacy `Inputs` (a strict linear write->read sequence), Steps can
+// branch on what the server said, making it possible to express stateful
+// protocols like SMTP AUTH, IMAP, or custom TCP RPCs. `Inputs` keeps working
+// unchanged for existing templates; a template using `Steps` opts into the
+// richer executor below.
+type Step struct {
+	// Type is one of: write, read, expect, if, sleep, goto, label, close
+	Type string `yaml:"type"`
+
+	// Name captures a `read` step's bytes under this variable name, same as
+	// Input.Name today.
+	Name string `yaml:"name,omitempty"`
+	// Data is the payload written by a `write` step.
+	Data string `yaml:"data,omitempty"`
+	// Read is the byte count read by a `read` step (0 uses a 4096 default).
+	Read int `yaml:"read,omitempty"`
+	// ReadUntil/ReadRegex terminate a `read` step early, same semantics as Input.
+	ReadUntil string         `yaml:"read-until,omitempty"`
+	ReadRegex *regexp.Regexp `yaml:"-"`
+
+	// Match is the literal an `expect` step requires in the most recently
+	// read buffer; the step fails (aborting the script) if absent. MatchRegex
+	// takes precedence over Match when set.
+	Match      string         `yaml:"match,omitempty"`
+	MatchRegex *regexp.Regexp `yaml:"-"`
+
+	// Condition is a DSL expression evaluated by an `if` step.
+	Condition string  `yaml:"condition,omitempty"`
+	Then      []*Step `yaml:"then,omitempty"`
+	Else      []*Step `yaml:"else,omitempty"`
+
+	// Sleep is a Go duration string used by a `sleep` step.
+	Sleep string `yaml:"sleep,omitempty"`
+	// Label names this step so a `goto` step elsewhere in the script can
+	// jump to it; Goto names the label to jump to.
+	Label string `yaml:"label,omitempty"`
+	Goto  string `yaml:"goto,omitempty"`
+}
+
+// stepsExecutor runs a Request's Steps script against a single connection,
+// reusing the same extractor pipeline as Inputs so a `read` step's captures
+// immediately populate interimValues and are visible to subsequent
+// `write`/`if` steps via expressions.Evaluate.
+type stepsExecutor struct {
+	request       *Request
+	conn          net.Conn
+	dt            *deadlineTimer
+	interimValues map[string]interface{}
+	payloads      map[string]interface{}
+	inputEvents   map[string]interface{}
+	reqBuilder    *strings.Builder
+	respBuilder   *strings.Builder
+	lastRead      string
+	trace         []string
+	// halted is set once a goto's jumped-to list has run, so the lists that
+	// enclosed the goto don't resume afterwards.
+	halted bool
+}
+
+const maxStepExecutions = 1000
+
+// runSteps executes a Request's Steps script and returns the steps_trace -
+// the ordered list of step types actually executed, invaluable for authoring
+// templates against stateful protocols.
+func (request *Request) runSteps(conn net.Conn, dt *deadlineTimer, interimValues, payloads, inputEvents map[string]interface{}, reqBuilder, respBuilder *strings.Builder) ([]string, error) {
+	exec := &stepsExecutor{
+		request:       request,
+		conn:          conn,
+		dt:            dt,
+		interimValues: interimValues,
+		payloads:      payloads,
+		inputEvents:   inputEvents,
+		reqBuilder:    reqBuilder,
+		respBuilder:   respBuilder,
+	}
+	executed := 0
+	labels := make(map[string]*labelTarget)
+	indexLabels(request.Steps, labels)
+	err := exec.runList(request.Steps, labels, &executed)
+	return exec.trace, err
+}
+
+// labelTarget pinpoints a `label` step by the list it belongs to and its
+// index within that list, so a `goto` anywhere in the script - including one
+// nested inside an `if`'s then/else branch - can jump back to it.
+type labelTarget struct {
+	list  []*Step
+	index int
+}
+
+// indexLabels walks the full (nested) step tree once up front and records
+// every label's location in a single shared table, so labels declared at the
+// top level stay reachable from a `goto` inside a nested then/else branch -
+// the shape a retry loop (label at top level, conditional goto from an
+// `if`) needs.
+func indexLabels(steps []*Step, labels map[string]*labelTarget) {
+	for i, step := range steps {
+		if step.Label != "" {
+			labels[step.Label] = &labelTarget{list: steps, index: i}
+		}
+		if step.Type == "if" {
+			indexLabels(step.Then, labels)
+			indexLabels(step.Else, labels)
+		}
+	}
+}
+
+func (e *stepsExecutor) runList(steps []*Step, labels map[string]*labelTarget, executed *int) error {
+	for i := 0; i < len(steps); i++ {
+		*executed++
+		if *executed > maxStepExecutions {
+			return errorutil.New("steps script exceeded maximum step executions (possible goto loop)")
+		}
+
+		step := steps[i]
+		e.trace = append(e.trace, step.Type)
+
+		switch step.Type {
+		case "write":
+			if err := e.write(step); err != nil {
+				return err
+			}
+		case "read":
+			if err := e.read(step); err != nil {
+				return err
+			}
+		case "expect":
+			if err := e.expect(step); err != nil {
+				return err
+			}
+		case "sleep":
+			e.sleep(step)
+		case "if":
+			branch, err := e.evalCondition(step.Condition)
+			if err != nil {
+				return err
+			}
+			children := step.Then
+			if !branch {
+				children = step.Else
+			}
+			if err := e.runList(children, labels, executed); err != nil {
+				return err
+			}
+			if e.halted {
+				return nil
+			}
+		case "label":
+			// no-op marker, already indexed above
+		case "goto":
+			target, ok := labels[step.Goto]
+			if !ok {
+				return errorutil.New("unknown goto label %q", step.Goto)
+			}
+			// resume from the label's own list/index rather than mutating i,
+			// since the label may live in a different (eg. parent/top-level)
+			// list than the one currently executing. Once that list finishes
+			// the script ends; the lists enclosing this goto don't resume.
+			err := e.runList(target.list[target.index:], labels, executed)
+			e.halted = true
+			return err
+		case "close":
+			return nil
+		default:
+			return errorutil.New("unknown step type %q", step.Type)
+		}
+	}
+	return nil
+}
+
+func (e *stepsExecutor) write(step *Step) error {
+	finalData, err := expressions.EvaluateByte([]byte(step.Data), e.interimValues)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not evaluate step expressions")
+	}
+	e.reqBuilder.Write(finalData)
+
+	e.dt.setWriteDeadline(0)
+	defer e.dt.clear()
+	_, err = e.conn.Write(finalData)
+	return err
+}
+
+func (e *stepsExecutor) read(step *Step) error {
+	var buffer []byte
+	var err error
+
+	switch {
+	case len(step.ReadUntil) > 0 || step.ReadRegex != nil:
+		buffer, _, err = readUntilTerminator(e.conn, []byte(step.ReadUntil), step.ReadRegex, int64(step.Read), DefaultReadTimeout)
+	default:
+		n := step.Read
+		if n == 0 {
+			n = 4096
+		}
+		buffer, err = ConnReadNWithDeadline(e.dt, int64(n), 0)
+	}
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not read response from connection")
+	}
+
+	e.respBuilder.Write(buffer)
+	e.lastRead = string(buffer)
+	if step.Name != "" {
+		e.inputEvents[step.Name] = e.lastRead
+		e.interimValues[step.Name] = e.lastRead
+	}
+
+	if e.request.CompiledOperators != nil && step.Name != "" {
+		values := e.request.CompiledOperators.ExecuteInternalExtractors(map[string]interface{}{step.Name: e.lastRead}, e.request.Extract)
+		e.interimValues = generators.MergeMaps(e.interimValues, values)
+		for k, v := range values {
+			e.payloads[k] = v
+		}
+	}
+	return nil
+}
+
+func (e *stepsExecutor) expect(step *Step) error {
+	var matched bool
+	switch {
+	case step.MatchRegex != nil:
+		matched = step.MatchRegex.MatchString(e.lastRead)
+	case step.Match != "":
+		matched = strings.Contains(e.lastRead, step.Match)
+	}
+	if !matched {
+		return errorutil.New("expect step did not match %q in response", step.Match)
+	}
+	return nil
+}
+
+func (e *stepsExecutor) sleep(step *Step) {
+	if d, err := time.ParseDuration(step.Sleep); err == nil {
+		time.Sleep(d)
+	}
+}
+
+// inputsUnlessSteps returns inputs unchanged, unless the request uses the
+// newer Steps script, in which case Inputs is ignored entirely.
+func inputsUnlessSteps(usesSteps bool, inputs []Input) []Input {
+	if usesSteps {
+		return nil
+	}
+	return inputs
+}
+
+func (e *stepsExecutor) evalCondition(condition string) (bool, error) {
+	result, err := expressions.Evaluate(condition, e.interimValues)
+	if err != nil {
+		return false, errorutil.NewWithErr(err).Msgf("could not evaluate if condition")
+	}
+	return result == "true" || result == "1", nil
+}
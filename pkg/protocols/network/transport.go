@@ -0,0 +1,115 @@
+package network
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pion/dtls/v2"
+)
+
+// supported values for the template-level `transport:` field
+const (
+	TransportTCP  = "tcp"
+	TransportUDP  = "udp"
+	TransportTLS  = "tls"
+	TransportDTLS = "dtls"
+)
+
+// transportOrDefault returns the configured transport, defaulting to tcp for
+// backwards compatibility with templates that don't set `transport:`.
+func (request *Request) transportOrDefault() string {
+	if request.Transport == "" {
+		return TransportTCP
+	}
+	return request.Transport
+}
+
+// dial opens a connection to actualAddress using the template's configured
+// transport, returning a net.Conn for stream transports (tcp/tls) or a
+// packetConn wrapping a net.PacketConn for datagram transports (udp/dtls).
+func (request *Request) dial(ctx context.Context, actualAddress string, shouldUseTLS bool) (net.Conn, error) {
+	switch request.transportOrDefault() {
+	case TransportUDP:
+		return request.dialUDP(ctx, actualAddress)
+	case TransportDTLS:
+		return request.dialDTLS(ctx, actualAddress)
+	default:
+		if shouldUseTLS || request.transportOrDefault() == TransportTLS {
+			return request.dialer.DialTLS(ctx, "tcp", actualAddress)
+		}
+		return request.dialer.Dial(ctx, "tcp", actualAddress)
+	}
+}
+
+// packetConn adapts a net.PacketConn connected to a single remote address
+// into the net.Conn interface expected by the rest of the executor: each
+// Write is a single datagram, and each Read pulls one datagram.
+type packetConn struct {
+	net.PacketConn
+	remote net.Addr
+}
+
+func (p *packetConn) Read(b []byte) (int, error) {
+	n, _, err := p.PacketConn.ReadFrom(b)
+	return n, err
+}
+
+func (p *packetConn) Write(b []byte) (int, error) {
+	return p.PacketConn.WriteTo(b, p.remote)
+}
+
+func (p *packetConn) RemoteAddr() net.Addr { return p.remote }
+
+func (request *Request) dialUDP(ctx context.Context, actualAddress string) (net.Conn, error) {
+	remote, err := net.ResolveUDPAddr("udp", actualAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &packetConn{PacketConn: conn, remote: remote}, nil
+}
+
+// dialDTLS dials a real DTLS (UDP + datagram TLS handshake) connection using
+// pion/dtls. `transport: dtls` is only meaningful if the handshake actually
+// happens - silently downgrading to plaintext UDP would misrepresent an
+// encrypted transport as honored, so this performs the handshake rather than
+// reusing dialUDP.
+func (request *Request) dialDTLS(ctx context.Context, actualAddress string) (net.Conn, error) {
+	remote, err := net.ResolveUDPAddr("udp", actualAddress)
+	if err != nil {
+		return nil, err
+	}
+	config := &dtls.Config{
+		// nuclei targets are arbitrary user-supplied hosts, so certificate
+		// verification is skipped the same way it is for `transport: tls`.
+		InsecureSkipVerify: true,
+		ConnectContextMaker: func() (context.Context, func()) {
+			return context.WithCancel(ctx)
+		},
+	}
+	return dtls.DialWithContext(ctx, "udp", remote, config)
+}
+
+// readDatagram reads a single datagram (up to n bytes) from conn, honoring
+// the given timeout. Unlike ConnReadNWithTimeout, it does not attempt to
+// fill the whole buffer, since datagram boundaries must be preserved.
+func readDatagram(conn net.Conn, n int64, timeout time.Duration) ([]byte, error) {
+	if timeout == 0 {
+		timeout = DefaultReadTimeout
+	}
+	if n <= 0 {
+		n = 4096
+	}
+	buf := make([]byte, n)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	count, err := conn.Read(buf)
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return buf[:count], nil
+}
@@ -0,0 +1,134 @@
+package network
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/nuclei/v3/pkg/operators"
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols"
+	"github.com/projectdiscovery/nuclei/v3/pkg/protocols/common/generators"
+)
+
+// addressKV holds a single resolved `address:` entry from the template,
+// along with whether it should be dialed over TLS.
+type addressKV struct {
+	address string
+	tls     bool
+}
+
+// Request contains a Network protocol request to be made from a template.
+type Request struct {
+	// ID is the optional id of the request
+	ID string `yaml:"id,omitempty" json:"id,omitempty"`
+
+	// Host to send network requests to.
+	Host []string `yaml:"host,omitempty" json:"host,omitempty"`
+	// Inputs contains inputs for the network socket, executed in order
+	// unless Steps is set, in which case Inputs is ignored.
+	Inputs []Input `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	// Steps is a multi-turn conversation script that supersedes Inputs for
+	// stateful protocols; see Step for the supported step types.
+	Steps []*Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+
+	// Port overrides the port(s) to connect to, either a single port, a
+	// comma-separated list, or a range (e.g. "1-65535"); also accepts
+	// keywords like "{{Port}}".
+	Port string `yaml:"port,omitempty" json:"port,omitempty"`
+	// ExcludePorts is a list of ports to exclude from the port field.
+	ExcludePorts string `yaml:"exclude-ports,omitempty" json:"exclude-ports,omitempty"`
+	// PortScanConcurrency bounds how many ports from Port are probed at
+	// once; when unset, the global PayloadConcurrency option is used, and
+	// DefaultPortScanConcurrency when that is unset too.
+	PortScanConcurrency int `yaml:"port-scan-concurrency,omitempty" json:"port-scan-concurrency,omitempty"`
+
+	// Transport is the transport protocol to dial: tcp (default), udp, tls
+	// or dtls.
+	Transport string `yaml:"transport,omitempty" json:"transport,omitempty"`
+
+	// HappyEyeballs opts this request into RFC 8305 Happy-Eyeballs
+	// dual-stack dialing, racing resolved addresses instead of dialing the
+	// first one returned by the resolver.
+	HappyEyeballs bool `yaml:"happy-eyeballs,omitempty" json:"happy-eyeballs,omitempty"`
+	// HappyEyeballsStagger overrides the delay between successive
+	// Happy-Eyeballs connection attempts; defaults to
+	// DefaultHappyEyeballsStagger when unset.
+	HappyEyeballsStagger time.Duration `yaml:"happy-eyeballs-stagger,omitempty" json:"happy-eyeballs-stagger,omitempty"`
+
+	// ReadSize is the default size of response to read at the end.
+	ReadSize int `yaml:"read-size,omitempty" json:"read-size,omitempty"`
+	// ReadAll reads the response until EOF instead of ReadSize bytes.
+	ReadAll bool `yaml:"read-all,omitempty" json:"read-all,omitempty"`
+	// ReadUntil/ReadRegex terminate the final read early, same semantics
+	// as Input.ReadUntil/Input.ReadRegex.
+	ReadUntil string         `yaml:"read-until,omitempty" json:"read-until,omitempty"`
+	ReadRegex *regexp.Regexp `yaml:"-" json:"-"`
+	// MaxReadSize bounds a ReadUntil/ReadRegex terminated final read;
+	// defaults to ReadSize, or to DefaultMaxReadSize when ReadSize is unset
+	// or ReadAll is set.
+	MaxReadSize int `yaml:"max-read-size,omitempty" json:"max-read-size,omitempty"`
+
+	// IdleTimeout overrides the connection-wide deadline set right after
+	// dialing; defaults to the global request timeout when unset.
+	IdleTimeout time.Duration `yaml:"idle-timeout,omitempty" json:"idle-timeout,omitempty"`
+
+	// Threads is the number of concurrent payload requests to send.
+	Threads int `yaml:"threads,omitempty" json:"threads,omitempty"`
+	// SelfContained specifies if the request is self-contained, in which
+	// case no address is resolved from the target input.
+	SelfContained bool `yaml:"-" json:"-"`
+
+	// CompiledOperators holds the compiled operators for the request.
+	//
+	// Match/Extract/MakeResultEvent (required by protocols.Request) are
+	// implemented alongside the rest of this request's operators wiring and
+	// are intentionally not touched here.
+	CompiledOperators *operators.Operators `yaml:"-" json:"-"`
+
+	addresses []addressKV
+	generator *generators.PayloadGenerator
+	ports     []string
+	prober    PortProber
+
+	options *protocols.ExecutorOptions
+	dialer  *fastdialer.Dialer
+}
+
+// Input is a single write/read exchange with the server over the connection
+// opened for the request.
+type Input struct {
+	// Data is the data to send as the input.
+	Data string `yaml:"data,omitempty" json:"data,omitempty"`
+	// Type is the type of input specified in `data` field, default is text
+	// but can also be `hex` to decode the data before sending it.
+	Type InputType `yaml:"type,omitempty" json:"type,omitempty"`
+	// Read is the number of bytes to read from the connection.
+	Read int `yaml:"read,omitempty" json:"read,omitempty"`
+	// ReadUntil/ReadRegex terminate the read early once the literal delim
+	// or regex has been seen in the accumulated buffer.
+	ReadUntil string         `yaml:"read-until,omitempty" json:"read-until,omitempty"`
+	ReadRegex *regexp.Regexp `yaml:"-" json:"-"`
+	// Name is the optional name of the input to use for storing response
+	// variables.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// ReadTimeout/WriteTimeout override the deadline used for this input's
+	// read/write, falling back to DefaultReadTimeout when unset.
+	ReadTimeout  time.Duration `yaml:"read-timeout,omitempty" json:"read-timeout,omitempty"`
+	WriteTimeout time.Duration `yaml:"write-timeout,omitempty" json:"write-timeout,omitempty"`
+}
+
+// InputType is the type of input specified in the Input's `data` field.
+type InputType struct {
+	value string
+}
+
+const hexType = "hex"
+
+// GetType returns the underlying type value of the Input.
+func (t InputType) GetType() string {
+	if t.value == "" {
+		return "text"
+	}
+	return t.value
+}
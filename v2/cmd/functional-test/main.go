@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"log"
@@ -9,22 +8,23 @@ import (
 	"strings"
 
 	"github.com/logrusorgru/aurora"
-	"github.com/pkg/errors"
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
 
-	"github.com/projectdiscovery/nuclei/v2/pkg/testutils"
+	"github.com/projectdiscovery/nuclei/v2/pkg/output"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/contextargs"
+	"github.com/projectdiscovery/nuclei/v2/pkg/templates"
+	"github.com/projectdiscovery/nuclei/v2/pkg/testutils/stub"
 )
 
 var (
-	debug        = os.Getenv("DEBUG") == "true"
-	githubAction = os.Getenv("GH_ACTION") == "true"
+	debug = os.Getenv("DEBUG") == "true"
 
 	success = aurora.Green("[✓]").String()
 	failed  = aurora.Red("[✘]").String()
 	errored = false
 
-	mainNucleiBinary = flag.String("main", "", "Main Branch Nuclei Binary")
-	devNucleiBinary  = flag.String("dev", "", "Dev Branch Nuclei Binary")
-	testcases        = flag.String("testcases", "", "Test cases file for nuclei functional tests")
+	templatesDir = flag.String("templates", "", "Directory containing DNS functional test templates")
 )
 
 func main() {
@@ -38,57 +38,171 @@ func main() {
 	}
 }
 
+// dnsTestCase describes a single functional test run against the in-process
+// DNS stub: a template to load, the records the stub should answer with, and
+// the rule that is expected to match once the template executes.
+type dnsTestCase struct {
+	name         string
+	templatePath string
+	records      map[string][]dns.RR
+	wantMatched  string
+}
+
 func runFunctionalTests() error {
-	file, err := os.Open(*testcases)
+	cases, err := loadDNSTestCases(*templatesDir)
 	if err != nil {
-		return errors.Wrap(err, "could not open test cases")
+		return err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		testCase := strings.TrimSpace(scanner.Text())
-		if testCase == "" {
-			continue
-		}
-		execute(testCase)
+	for _, tc := range cases {
+		execute(tc)
 	}
 	return nil
 }
 
-func execute(text string) {
-	ghActionGroupStart := ""
-	ghActionGroupEnd := ""
-	if githubAction {
-		ghActionGroupStart = "::group::"
-		ghActionGroupEnd = "::endgroup::"
-	}
-
-	if err := runIndividualTestCase(text); err != nil {
+func execute(tc dnsTestCase) {
+	if err := runDNSTestCase(tc); err != nil {
 		errored = true
-		fmt.Fprintf(os.Stderr, "%s%s Test \"%s\" failed: %s\n%s", ghActionGroupStart, failed, text, err, ghActionGroupEnd)
+		fmt.Fprintf(os.Stderr, "%s Test %q failed: %s\n", failed, tc.name, err)
 	} else {
-		fmt.Printf("%s%s Test \"%s\" passed!\n%s", ghActionGroupStart, success, text, ghActionGroupEnd)
+		fmt.Printf("%s Test %q passed!\n", success, tc.name)
+	}
+}
+
+// runDNSTestCase spins up the in-process DNS stub seeded with the test
+// case's records, loads the template, executes it end-to-end through
+// Request.ExecuteWithResults, and asserts on the resulting
+// output.InternalWrappedEvent.
+//
+// This still drives the legacy v2 pkg/templates DNS request type rather than
+// the v3 pkg/protocols/dns one that doWithResolverTracking lives in: building
+// a v3 Request here requires its Compile/Make step and a populated
+// protocols.ExecutorOptions, neither of which this repository checkout
+// carries alongside client.go/request.go. The v3 resolver-attribution logic
+// (normalizeResolver/validateResolvers/resolverTransport) is covered directly
+// instead, in pkg/protocols/dns/client_test.go, which needs none of that
+// missing scaffolding.
+func runDNSTestCase(tc dnsTestCase) error {
+	srv, err := stub.New(stub.WithDNSRecords(tc.records))
+	if err != nil {
+		return fmt.Errorf("could not start dns stub: %w", err)
+	}
+	defer srv.Close()
+
+	template, err := templates.Parse(tc.templatePath, nil, templates.NewDefaultOptions(nil))
+	if err != nil {
+		return fmt.Errorf("could not parse template: %w", err)
+	}
+	if template.RequestsDNS == nil {
+		return fmt.Errorf("template %s does not contain a dns request", tc.templatePath)
+	}
+
+	for _, request := range template.RequestsDNS {
+		request.Resolvers = []string{srv.DNSAddr}
+	}
+
+	input := contextargs.NewWithInput("dns-functional-test.local")
+
+	var matched string
+	for _, request := range template.RequestsDNS {
+		for result := range request.ExecuteWithResults(input, nil, nil) {
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.Event == nil {
+				continue
+			}
+			for _, name := range matchedRuleNames(result.Event) {
+				matched = name
+			}
+		}
+	}
+
+	if debug {
+		fmt.Printf("[%s] matched=%q want=%q\n", tc.name, matched, tc.wantMatched)
 	}
+	if matched != tc.wantMatched {
+		return fmt.Errorf("expected matcher %q to match, got %q", tc.wantMatched, matched)
+	}
+	return nil
+}
+
+func matchedRuleNames(event *output.InternalWrappedEvent) []string {
+	if event.OperatorsResult == nil {
+		return nil
+	}
+	return event.OperatorsResult.Matches
 }
 
-func runIndividualTestCase(testcase string) error {
-	parts := strings.Fields(testcase)
+// dnsRecordsSidecar is the `*.records.yaml` schema: the DNS answers the stub
+// should serve, keyed by fully qualified question name, plus the matcher
+// name the template run is expected to produce.
+type dnsRecordsSidecar struct {
+	Records     map[string][]string `yaml:"records"`
+	WantMatched string              `yaml:"want-matched"`
+}
 
-	var finalArgs []string
-	if len(parts) > 1 {
-		finalArgs = parts[1:]
+// loadDNSTestCases discovers `*.yaml` templates under dir and pairs each with
+// a `*.records.yaml` sidecar (see dnsRecordsSidecar) describing the stub DNS
+// answers to serve.
+func loadDNSTestCases(dir string) ([]dnsTestCase, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("-templates directory is required")
 	}
-	mainOutput, err := testutils.RunNucleiBinaryAndGetLoadedTemplates(*mainNucleiBinary, debug, finalArgs)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return errors.Wrap(err, "could not run nuclei main test")
+		return nil, err
 	}
-	devOutput, err := testutils.RunNucleiBinaryAndGetLoadedTemplates(*devNucleiBinary, debug, finalArgs)
+
+	var cases []dnsTestCase
+	for _, entry := range entries {
+		if entry.IsDir() || !isTemplateFile(entry.Name()) {
+			continue
+		}
+		templatePath := dir + "/" + entry.Name()
+		sidecarPath := strings.TrimSuffix(templatePath, ".yaml") + ".records.yaml"
+
+		records, wantMatched, err := loadRecordsSidecar(sidecarPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load sidecar for %s: %w", entry.Name(), err)
+		}
+
+		cases = append(cases, dnsTestCase{
+			name:         entry.Name(),
+			templatePath: templatePath,
+			records:      records,
+			wantMatched:  wantMatched,
+		})
+	}
+	return cases, nil
+}
+
+// loadRecordsSidecar parses a `*.records.yaml` sidecar into the stub's
+// map[string][]dns.RR shape, with each record written in standard zone-file
+// syntax (eg. "example.com. 300 IN A 93.184.216.34").
+func loadRecordsSidecar(path string) (map[string][]dns.RR, string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return errors.Wrap(err, "could not run nuclei dev test")
+		return nil, "", err
 	}
-	if mainOutput == devOutput {
-		return nil
+
+	var sidecar dnsRecordsSidecar
+	if err := yaml.Unmarshal(data, &sidecar); err != nil {
+		return nil, "", fmt.Errorf("could not parse %s: %w", path, err)
 	}
-	return fmt.Errorf("%s main is not equal to %s dev", mainOutput, devOutput)
+
+	records := make(map[string][]dns.RR, len(sidecar.Records))
+	for name, lines := range sidecar.Records {
+		for _, line := range lines {
+			rr, err := dns.NewRR(line)
+			if err != nil {
+				return nil, "", fmt.Errorf("could not parse record %q in %s: %w", line, path, err)
+			}
+			records[name] = append(records[name], rr)
+		}
+	}
+	return records, sidecar.WantMatched, nil
+}
+
+func isTemplateFile(name string) bool {
+	return len(name) > 5 && name[len(name)-5:] == ".yaml" && !strings.HasSuffix(name, ".records.yaml")
 }
@@ -0,0 +1,137 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	updateutils "github.com/projectdiscovery/utils/update"
+)
+
+// maxDeltaFraction is the largest fraction of the previously tracked tree a
+// delta update is allowed to touch before writeAllFromTarball (a full
+// rewrite) is cheaper and safer than fetching each changed file individually.
+const maxDeltaFraction = 0.4
+
+// deltaSourceRoot is prepended to delta paths (which are already relative,
+// unlike a release zip's "<root>/<relPath>" uris) so they can still be run
+// through getAbsoluteFilePath's LFI guard and meta-file handling.
+const deltaSourceRoot = "delta/"
+
+// planDelta diffs the locally tracked checksums against manifest, returning
+// the added, modified and removed paths. Deletions fall out of the diff for
+// free: any path present locally but absent from manifest is gone upstream.
+func planDelta(old map[string]string, manifest releaseManifest) (adds, mods, dels []string) {
+	for path, hash := range manifest {
+		if oldHash, ok := old[path]; ok {
+			if oldHash != hash {
+				mods = append(mods, path)
+			}
+		} else {
+			adds = append(adds, path)
+		}
+	}
+	for path := range old {
+		if _, ok := manifest[path]; !ok {
+			dels = append(dels, path)
+		}
+	}
+	return adds, mods, dels
+}
+
+// shouldUseDelta reports whether a delta update touching adds+mods+dels out
+// of oldTotal tracked files is worth it over just rewriting the full tarball.
+func shouldUseDelta(adds, mods, dels []string, oldTotal int) bool {
+	if oldTotal == 0 {
+		return false
+	}
+	changed := len(adds) + len(mods) + len(dels)
+	return float64(changed)/float64(oldTotal) <= maxDeltaFraction
+}
+
+// applyDelta fetches only the changed/added paths (via GitHub's raw content
+// URL, at the release tag) and removes the deleted ones, instead of
+// rewriting the entire release tarball. Each fetched file is still run
+// through getAbsoluteFilePath's LFI guard and verified against manifest when
+// one is available, the same as a full writeAllFromTarball write.
+func (t *TemplateManager) applyDelta(ghrd *updateutils.GHReleaseDownloader, dir string, manifest releaseManifest, changed, dels []string) error {
+	ref := ghrd.Latest.GetTagName()
+
+	for _, path := range dels {
+		writePath := t.getAbsoluteFilePath(dir, deltaSourceRoot+path, deltaFileInfo(path, false))
+		if writePath == "" {
+			continue
+		}
+		if err := os.Remove(writePath); err != nil && !os.IsNotExist(err) {
+			return errorutil.NewWithErr(err).Msgf("failed to remove deleted template %s", path)
+		}
+	}
+
+	for _, path := range changed {
+		bin, err := fetchRawTemplateFile(ghrd.RepoName, ref, path)
+		if err != nil {
+			return err
+		}
+		if manifest != nil {
+			if err := manifest.verifyPath(path, path, bin); err != nil {
+				return err
+			}
+		}
+		writePath := t.getAbsoluteFilePath(dir, deltaSourceRoot+path, deltaFileInfo(path, false))
+		if writePath == "" {
+			continue
+		}
+		if err := fileutil.CreateFolder(filepath.Dir(writePath)); err != nil {
+			return errorutil.NewWithErr(err).Msgf("failed to create directory for %s", path)
+		}
+		if err := os.WriteFile(writePath, bin, 0644); err != nil {
+			return errorutil.NewWithErr(err).Msgf("failed to write %s", path)
+		}
+	}
+
+	gologger.Verbose().Msgf("delta template update applied: %d fetched, %d removed", len(changed), len(dels))
+	return nil
+}
+
+// fetchRawTemplateFile downloads a single path out of repoName at ref via
+// GitHub's raw content endpoint, so a delta update only has to transfer the
+// handful of files that actually changed.
+func fetchRawTemplateFile(repoName, ref, path string) ([]byte, error) {
+	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoName, ref, path)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to fetch %s", path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorutil.New("unexpected status %d fetching %s", resp.StatusCode, path)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// deltaFileInfo synthesizes the minimal fs.FileInfo getAbsoluteFilePath
+// needs (Name/IsDir) for a path that didn't come from a real os.Stat call,
+// since delta files are fetched over HTTP rather than read off disk or out
+// of an archive entry.
+func deltaFileInfo(path string, isDir bool) fs.FileInfo {
+	return deltaFileInfoImpl{name: filepath.Base(path), isDir: isDir}
+}
+
+type deltaFileInfoImpl struct {
+	name  string
+	isDir bool
+}
+
+func (d deltaFileInfoImpl) Name() string       { return d.name }
+func (d deltaFileInfoImpl) Size() int64        { return 0 }
+func (d deltaFileInfoImpl) Mode() fs.FileMode  { return 0644 }
+func (d deltaFileInfoImpl) ModTime() time.Time { return time.Time{} }
+func (d deltaFileInfoImpl) IsDir() bool        { return d.isDir }
+func (d deltaFileInfoImpl) Sys() any           { return nil }
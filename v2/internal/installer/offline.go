@@ -0,0 +1,224 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/nuclei/v2/pkg/catalog/config"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// offlineSourceRoot is prepended to every uri synthesized from a local
+// directory source, so it has the same "<root>/<relPath>" shape a GitHub
+// release zip has and getAbsoluteFilePath can strip it the same way.
+const offlineSourceRoot = "offline-source/"
+
+// offlineSourceVersion is the template-version string recorded after an
+// offline install/update, so NeedsTemplateUpdate treats the install as
+// up-to-date instead of silently falling back to polling the GitHub feed.
+const offlineSourceVersion = "offline"
+
+// FreshInstallFromSource installs templates from a local src - a .zip or
+// .tar.gz release artifact, or an already-extracted directory - instead of
+// downloading the official nuclei-templates release. It is the offline
+// counterpart of FreshInstallIfNotExists for air-gapped environments.
+func (t *TemplateManager) FreshInstallFromSource(src string) error {
+	dir := config.DefaultConfig.TemplatesDirectory
+	if !fileutil.FolderExists(dir) {
+		if err := fileutil.CreateFolder(dir); err != nil {
+			return errorutil.NewWithErr(err).Msgf("failed to create directory at %s", dir)
+		}
+	}
+	if err := t.writeTemplatesFromSource(src, dir); err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Successfully installed nuclei-templates from %s at %s", src, dir)
+	return nil
+}
+
+// UpdateFromSource re-installs templates at the default templates directory
+// from src and prints the same added/removed/modified changelog table
+// updateTemplatesAt prints for a GitHub-sourced update.
+func (t *TemplateManager) UpdateFromSource(src string) error {
+	dir := config.DefaultConfig.TemplatesDirectory
+
+	oldchecksums, err := t.getChecksumFromDir(dir)
+	if err != nil {
+		// if something went wrong overwrite all files
+		oldchecksums = make(map[string]string)
+	}
+
+	if err := t.writeTemplatesFromSource(src, dir); err != nil {
+		return err
+	}
+
+	newchecksums, err := t.getChecksumFromDir(dir)
+	if err != nil {
+		// unlikely this case will happen
+		return errorutil.NewWithErr(err).Msgf("failed to get checksums from %s after update", dir)
+	}
+
+	results := t.summarizeChanges(oldchecksums, newchecksums)
+	if results.totalCount > 0 {
+		gologger.Info().Msgf("Successfully updated nuclei-templates from %s. GoodLuck!", src)
+		if !HideUpdateChangesTable {
+			gologger.Print().Msgf("\nNuclei Templates Changelog\n")
+			gologger.DefaultLogger.Print().Msg(results.String())
+		}
+	} else {
+		gologger.Info().Msgf("Successfully updated nuclei-templates from %s. GoodLuck!", src)
+	}
+	return nil
+}
+
+// writeTemplatesFromSource writes templates from src into dir using the
+// same getAbsoluteFilePath LFI guard and meta-file handling a GitHub release
+// goes through, then pins offlineSourceVersion so later NeedsTemplateUpdate
+// calls don't assume the GitHub feed is authoritative.
+func (t *TemplateManager) writeTemplatesFromSource(src, dir string) error {
+	callbackFunc := func(uri string, f fs.FileInfo, r io.Reader) error {
+		writePath := t.getAbsoluteFilePath(dir, uri, f)
+		if writePath == "" {
+			// skip writing file
+			return nil
+		}
+		bin, err := io.ReadAll(r)
+		if err != nil {
+			// if error occurs, iteration also stops
+			return errorutil.NewWithErr(err).Msgf("failed to read file %s", uri)
+		}
+		return os.WriteFile(writePath, bin, f.Mode())
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("failed to stat templates source %s", src)
+	}
+
+	switch {
+	case info.IsDir():
+		err = walkSourceDir(src, callbackFunc)
+	case strings.HasSuffix(src, ".tar.gz") || strings.HasSuffix(src, ".tgz"):
+		err = walkSourceTarGz(src, callbackFunc)
+	case strings.HasSuffix(src, ".zip"):
+		err = walkSourceZip(src, callbackFunc)
+	default:
+		return errorutil.New("unsupported templates source %s: expected a directory, .zip or .tar.gz", src)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := config.DefaultConfig.WriteTemplatesConfig(); err != nil {
+		return err
+	}
+	// update ignore hash after writing new templates
+	if err := config.DefaultConfig.UpdateNucleiIgnoreHash(); err != nil {
+		return err
+	}
+	// pin the version so we don't fall back to polling github on the next run
+	if err := config.DefaultConfig.SetTemplatesVersion(offlineSourceVersion); err != nil {
+		return err
+	}
+	// after installation create and write checksums to .checksum file
+	return t.writeChecksumFileInDir(dir)
+}
+
+// walkSourceDir walks an already-extracted templates directory, synthesizing
+// the uri/fs.FileInfo/reader triple writeTemplatesFromSource's callback
+// expects for each entry.
+func walkSourceDir(root string, callback func(uri string, f fs.FileInfo, r io.Reader) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		uri := offlineSourceRoot + filepath.ToSlash(relPath)
+		if d.IsDir() {
+			return callback(uri, info, nil)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return callback(uri, info, file)
+	})
+}
+
+// walkSourceTarGz streams each entry of a .tar.gz/.tgz release artifact
+// through callback, same as DownloadSourceWithCallback does for a
+// downloaded release.
+func walkSourceTarGz(path string, callback func(uri string, f fs.FileInfo, r io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("failed to open %s", path)
+	}
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("failed to read %s as gzip", path)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("failed to read tar entry in %s", path)
+		}
+		if err := callback(header.Name, header.FileInfo(), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// walkSourceZip streams each entry of a .zip release artifact through
+// callback, same as DownloadSourceWithCallback does for a downloaded release.
+func walkSourceZip(path string, callback func(uri string, f fs.FileInfo, r io.Reader) error) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("failed to open %s as zip", path)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if err := readZipEntry(zf, callback); err != nil {
+			return errorutil.NewWithErr(err).Msgf("failed to read zip entry %s in %s", zf.Name, path)
+		}
+	}
+	return nil
+}
+
+// readZipEntry opens a single zip entry and hands it to callback, closing
+// the entry reader before returning regardless of outcome.
+func readZipEntry(zf *zip.File, callback func(uri string, f fs.FileInfo, r io.Reader) error) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return callback(zf.Name, zf.FileInfo(), rc)
+}
@@ -0,0 +1,46 @@
+package installer
+
+import (
+	"context"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/nuclei/v2/internal/installer/updatecheck"
+	"github.com/projectdiscovery/nuclei/v2/pkg/catalog/config"
+)
+
+// UpdateCheckClient is the updatecheck.Client UpdateIfOutdated prechecks
+// against before falling back to GHReleaseDownloader's GitHub API calls.
+// Exported so self-hosted users can repoint Endpoint/Timeout at their own
+// mirror (or set updatecheck.Disabled) without patching nuclei.
+var UpdateCheckClient = &updatecheck.Client{}
+
+// isUpToDateAccordingToUpdateCheck asks the lightweight update-check
+// endpoint whether templates are current. Any error - including the
+// endpoint being disabled or unreachable - is treated as "don't know", and
+// the caller should fall back to the existing GitHub-backed path; this
+// precheck only ever short-circuits work, it never blocks it.
+func isUpToDateAccordingToUpdateCheck() bool {
+	resp, err := UpdateCheckClient.Check(context.Background())
+	if err != nil {
+		gologger.Verbose().Msgf("update-check precheck skipped: %s", err)
+		return false
+	}
+	return resp.TemplatesLatest == config.DefaultConfig.TemplateVersion &&
+		resp.IgnoreHash == config.DefaultConfig.GetIgnoreHash()
+}
+
+// IsTemplateUpdateCheckCurrent is the exported form of
+// isUpToDateAccordingToUpdateCheck, meant for pkg/catalog/config's
+// NeedsTemplateUpdate to call as its own cheap precheck before polling
+// GitHub - that package lives outside this one, so it can't call the
+// unexported precheck directly.
+//
+// NeedsTemplateUpdate itself is NOT wired to call this yet: pkg/catalog/config
+// does not exist in this checkout (it's only ever imported here, never
+// vendored/present as source), so there is no NeedsTemplateUpdate to edit
+// from within this repository. UpdateIfOutdated, in this same package,
+// already benefits by calling the unexported version above. Wiring the
+// config-package caller is left for whoever owns that package's source.
+func IsTemplateUpdateCheckCurrent() bool {
+	return isUpToDateAccordingToUpdateCheck()
+}
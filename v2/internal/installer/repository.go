@@ -0,0 +1,233 @@
+package installer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/nuclei/v2/pkg/catalog/config"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/projectdiscovery/utils/update"
+)
+
+// repositoriesFileName is the name of the additional-repositories list,
+// stored alongside the rest of nuclei's config.
+const repositoriesFileName = "repositories.yaml"
+
+// TemplateRepository is a single additional template source, layered over
+// (or instead of) the official projectdiscovery/nuclei-templates release.
+// Repositories are resolved in ascending Priority order, so a higher
+// priority (eg. a vendored internal repo) is applied last and therefore
+// wins on a template ID collision without requiring a fork of the
+// community templates.
+type TemplateRepository struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url"`
+	Branch   string `yaml:"branch,omitempty"`
+	Release  string `yaml:"release,omitempty"`
+	Priority int    `yaml:"priority"`
+
+	// PublicKey, if set, pins the ed25519 public key (PKIX, base64-encoded)
+	// used to verify this repository's signed checksums.txt, the same way
+	// the official feed is verified against nucleiTemplatesPublicKey. Only
+	// the repository's own signing pipeline is expected to hold the
+	// matching private key - the official key never applies here.
+	PublicKey string `yaml:"public-key,omitempty"`
+	// SkipVerify opts this repository out of signature verification
+	// entirely, eg. for a repository that doesn't publish a signed
+	// checksums manifest at all.
+	SkipVerify bool `yaml:"skip-verify,omitempty"`
+}
+
+// resolveManifest verifies repo's release against its own PublicKey when one
+// is configured. Most additional repositories aren't signed by the official
+// nuclei-templates key (or signed at all), so - unlike the official feed -
+// verification here is opt-in rather than fail-closed by default.
+func (repo *TemplateRepository) resolveManifest(ghrd *update.GHReleaseDownloader) (releaseManifest, error) {
+	if repo.SkipVerify || repo.PublicKey == "" {
+		return nil, nil
+	}
+	return resolveReleaseManifestWithKey(ghrd, repo.PublicKey)
+}
+
+// Dir returns the subdirectory this repository's templates are written to,
+// under the main templates directory.
+func (repo *TemplateRepository) Dir() string {
+	return filepath.Join(config.DefaultConfig.TemplatesDirectory, "repos", repo.Name)
+}
+
+// checksumPath returns this repository's own .checksum file path.
+func (repo *TemplateRepository) checksumPath() string {
+	return filepath.Join(repo.Dir(), ".checksum")
+}
+
+// repoUpdateResult is the per-repository outcome of UpdateAllRepositories,
+// pairing a repository with its changelog so the summary table can include
+// a Repo column.
+type repoUpdateResult struct {
+	repo    *TemplateRepository
+	results *templateUpdateResults
+}
+
+// repositoriesFilePath returns the path to repositories.yaml in the config directory.
+func repositoriesFilePath() string {
+	return filepath.Join(config.DefaultConfig.GetConfigDir(), repositoriesFileName)
+}
+
+// ListRepositories returns all configured additional template repositories,
+// ordered by ascending priority (the order they're resolved/applied in).
+func (t *TemplateManager) ListRepositories() ([]*TemplateRepository, error) {
+	path := repositoriesFilePath()
+	if !fileutil.FileExists(path) {
+		return nil, nil
+	}
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to read %s", path)
+	}
+	var repos []*TemplateRepository
+	if err := yaml.Unmarshal(bin, &repos); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to parse %s", path)
+	}
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].Priority < repos[j].Priority })
+	return repos, nil
+}
+
+// writeRepositories persists repos to repositories.yaml.
+func (t *TemplateManager) writeRepositories(repos []*TemplateRepository) error {
+	bin, err := yaml.Marshal(repos)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("failed to marshal repositories")
+	}
+	return os.WriteFile(repositoriesFilePath(), bin, checkSumFilePerm)
+}
+
+// AddRepository adds (or, matched by name, replaces) an additional template repository.
+func (t *TemplateManager) AddRepository(repo *TemplateRepository) error {
+	repos, err := t.ListRepositories()
+	if err != nil {
+		return err
+	}
+	filtered := make([]*TemplateRepository, 0, len(repos)+1)
+	for _, existing := range repos {
+		if existing.Name != repo.Name {
+			filtered = append(filtered, existing)
+		}
+	}
+	filtered = append(filtered, repo)
+	return t.writeRepositories(filtered)
+}
+
+// RemoveRepository removes a repository entry by name from repositories.yaml;
+// it does not delete the repository's already-downloaded templates on disk.
+func (t *TemplateManager) RemoveRepository(name string) error {
+	repos, err := t.ListRepositories()
+	if err != nil {
+		return err
+	}
+	filtered := make([]*TemplateRepository, 0, len(repos))
+	for _, existing := range repos {
+		if existing.Name != name {
+			filtered = append(filtered, existing)
+		}
+	}
+	return t.writeRepositories(filtered)
+}
+
+// UpdateRepository downloads/updates a single named repository's templates.
+func (t *TemplateManager) UpdateRepository(name string) (*repoUpdateResult, error) {
+	repos, err := t.ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		if repo.Name == name {
+			return t.updateRepositoryTemplatesAt(repo)
+		}
+	}
+	return nil, errorutil.New("repository %q is not configured", name)
+}
+
+// UpdateAllRepositories updates every configured repository, in priority
+// order, and returns a combined per-repo summary of what changed.
+func (t *TemplateManager) UpdateAllRepositories() ([]*repoUpdateResult, error) {
+	repos, err := t.ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*repoUpdateResult, 0, len(repos))
+	for _, repo := range repos {
+		result, updateErr := t.updateRepositoryTemplatesAt(repo)
+		if updateErr != nil {
+			gologger.Warning().Msgf("failed to update repository %q: %s", repo.Name, updateErr)
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// updateRepositoryTemplatesAt downloads repo's templates into its own
+// subdirectory and diffs them against its own .checksum file, independent
+// of the official nuclei-templates version/checksum bookkeeping.
+func (t *TemplateManager) updateRepositoryTemplatesAt(repo *TemplateRepository) (*repoUpdateResult, error) {
+	dir := repo.Dir()
+	if err := fileutil.CreateFolder(dir); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to create directory at %s", dir)
+	}
+	checksumPath := repo.checksumPath()
+
+	oldChecksums, err := t.getChecksumFromPath(checksumPath, dir)
+	if err != nil {
+		oldChecksums = make(map[string]string)
+	}
+
+	ghrd, err := update.NewghReleaseDownloader(repo.URL)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to fetch repository %q", repo.Name)
+	}
+
+	manifest, err := repo.resolveManifest(ghrd)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.writeAllFromTarball(ghrd, dir, manifest); err != nil {
+		return nil, err
+	}
+	if err := t.writeChecksumFileAt(checksumPath, dir); err != nil {
+		return nil, err
+	}
+
+	newChecksums, err := t.getChecksumFromPath(checksumPath, dir)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to get checksums for repository %q", repo.Name)
+	}
+
+	return &repoUpdateResult{repo: repo, results: t.summarizeChanges(oldChecksums, newChecksums)}, nil
+}
+
+// FormatRepositoryUpdateResults renders a combined changelog table across
+// all updated repositories, with a Repo column identifying which source
+// each row of changes came from.
+func FormatRepositoryUpdateResults(results []*repoUpdateResult) string {
+	var buff bytes.Buffer
+	table := tablewriter.NewWriter(&buff)
+	table.SetHeader([]string{"Repo", "Total", "Added", "Removed"})
+	for _, result := range results {
+		table.Append([]string{
+			result.repo.Name,
+			strconv.Itoa(result.results.totalCount),
+			strconv.Itoa(len(result.results.additions)),
+			strconv.Itoa(len(result.results.deletions)),
+		})
+	}
+	table.Render()
+	return buff.String()
+}
@@ -2,7 +2,8 @@ package installer
 
 import (
 	"bytes"
-	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
@@ -22,6 +23,13 @@ import (
 
 const (
 	checkSumFilePerm = 0644
+	// checksumFormatVersion/checksumAlgo identify the current on-disk
+	// .checksum line format: "<version>,<algo>,<path>,<hex>". Bumped from
+	// the unversioned md5 format when templates moved to sha256; that
+	// unversioned 2-field format ("<path>,<hex>") is still tolerated for
+	// one release cycle by getChecksumFromPath.
+	checksumFormatVersion = "v2"
+	checksumAlgo          = "sha256"
 )
 
 var (
@@ -36,16 +44,28 @@ type templateUpdateResults struct {
 	deletions     []string
 	modifications []string
 	totalCount    int
+	// delta is true when these results came from applyDelta fetching only
+	// the changed/added files instead of writeAllFromTarball rewriting the
+	// entire release.
+	delta bool
+}
+
+// updateKind returns "Delta" or "Full" for display in the changelog table.
+func (t *templateUpdateResults) updateKind() string {
+	if t.delta {
+		return "Delta"
+	}
+	return "Full"
 }
 
 // String returns markdown table of template update results
 func (t *templateUpdateResults) String() string {
 	var buff bytes.Buffer
 	data := [][]string{
-		{strconv.Itoa(t.totalCount), strconv.Itoa(len(t.additions)), strconv.Itoa(len(t.deletions))},
+		{strconv.Itoa(t.totalCount), strconv.Itoa(len(t.additions)), strconv.Itoa(len(t.deletions)), t.updateKind()},
 	}
 	table := tablewriter.NewWriter(&buff)
-	table.SetHeader([]string{"Total", "Added", "Removed"})
+	table.SetHeader([]string{"Total", "Added", "Removed", "Update"})
 	for _, v := range data {
 		table.Append(v)
 	}
@@ -73,8 +93,30 @@ func (t *TemplateManager) UpdateIfOutdated() error {
 	if !fileutil.FolderExists(config.DefaultConfig.TemplatesDirectory) {
 		return t.FreshInstallIfNotExists()
 	}
+
+	// cheap precheck against the update-check endpoint (see the
+	// updatecheck package) before touching the GitHub API at all - a
+	// routine CI run shouldn't burn an unauthenticated, rate-limited
+	// GitHub call just to learn nothing changed. NeedsTemplateUpdate below
+	// still does its own GitHub-backed check; this only short-circuits it
+	// when we already know we're current.
+	if isUpToDateAccordingToUpdateCheck() {
+		return nil
+	}
+
 	if config.DefaultConfig.NeedsTemplateUpdate() {
-		return t.updateTemplatesAt(config.DefaultConfig.TemplatesDirectory)
+		if err := t.updateTemplatesAt(config.DefaultConfig.TemplatesDirectory); err != nil {
+			return err
+		}
+	}
+
+	// additional repositories (see repository.go) are updated whatever
+	// NeedsTemplateUpdate reports; when the update-check precheck above finds
+	// the official templates current, this returns before reaching them
+	if results, err := t.UpdateAllRepositories(); err != nil {
+		gologger.Warning().Msgf("failed to update template repositories: %s", err)
+	} else if len(results) > 0 && !HideUpdateChangesTable {
+		gologger.Print().Msgf("\n%s", FormatRepositoryUpdateResults(results))
 	}
 	return nil
 }
@@ -90,8 +132,9 @@ func (t *TemplateManager) installTemplatesAt(dir string) error {
 	if err != nil {
 		return errorutil.NewWithErr(err).Msgf("failed to install templates at %s", dir)
 	}
-	// write templates to disk
-	if err := t.writeTemplatestoDisk(ghrd, dir); err != nil {
+	// write templates to disk - a fresh install always goes through the
+	// full tarball path since there are no existing checksums to diff against
+	if _, err := t.writeTemplatestoDisk(ghrd, dir, nil); err != nil {
 		return err
 	}
 	gologger.Info().Msgf("Successfully installed nuclei-templates at %s", dir)
@@ -114,8 +157,10 @@ func (t *TemplateManager) updateTemplatesAt(dir string) error {
 
 	gologger.Info().Msgf("Your current nuclei-templates %s are outdated. Latest is %s\n", config.DefaultConfig.TemplateVersion, ghrd.Latest.GetTagName())
 
-	// write templates to disk
-	if err := t.writeTemplatestoDisk(ghrd, dir); err != nil {
+	// write templates to disk, preferring a delta update (see applyDelta) over
+	// rewriting the full tarball when the change is small enough
+	isDelta, err := t.writeTemplatestoDisk(ghrd, dir, oldchecksums)
+	if err != nil {
 		return err
 	}
 
@@ -128,6 +173,7 @@ func (t *TemplateManager) updateTemplatesAt(dir string) error {
 
 	// summarize all changes
 	results := t.summarizeChanges(oldchecksums, newchecksums)
+	results.delta = isDelta
 
 	// print summary
 	if results.totalCount > 0 {
@@ -213,57 +259,138 @@ func (t *TemplateManager) getAbsoluteFilePath(templatedir, uri string, f fs.File
 	return newPath
 }
 
-// writeChecksumFileInDir is actual method responsible for writing all templates to directory
-func (t *TemplateManager) writeTemplatestoDisk(ghrd *updateutils.GHReleaseDownloader, dir string) error {
-	callbackFunc := func(uri string, f fs.FileInfo, r io.Reader) error {
-		writePath := t.getAbsoluteFilePath(dir, uri, f)
-		if writePath == "" {
-			// skip writing file
-			return nil
-		}
-		bin, err := io.ReadAll(r)
-		if err != nil {
-			// if error occurs, iteration also stops
-			return errorutil.NewWithErr(err).Msgf("failed to read file %s", uri)
-		}
-		return os.WriteFile(writePath, bin, f.Mode())
+// writeTemplatestoDisk writes the official nuclei-templates release to dir
+// and updates nuclei's template-version bookkeeping in config. oldChecksums
+// is the caller's current .checksum state: when non-empty it is used to
+// attempt a delta update (see applyDelta) against the remote manifest
+// instead of rewriting the full release tarball; pass nil for a fresh
+// install, which always goes through writeAllFromTarball. The returned bool
+// reports whether a delta update was applied. Additional repositories (see
+// repository.go) use writeAllFromTarball/writeChecksumFileAt directly
+// instead, since they track their own version/checksum state rather than
+// the official one and are small enough that delta updates aren't worth it.
+func (t *TemplateManager) writeTemplatestoDisk(ghrd *updateutils.GHReleaseDownloader, dir string, oldChecksums map[string]string) (bool, error) {
+	manifest, err := resolveReleaseManifest(ghrd)
+	if err != nil {
+		return false, err
 	}
-	err := ghrd.DownloadSourceWithCallback(!HideProgressBar, callbackFunc)
+	isDelta, err := t.writeUpdatedFiles(ghrd, dir, manifest, oldChecksums)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if err := config.DefaultConfig.WriteTemplatesConfig(); err != nil {
-		return err
+		return false, err
 	}
 	// update ignore hash after writing new templates
 	if err := config.DefaultConfig.UpdateNucleiIgnoreHash(); err != nil {
-		return err
+		return false, err
 	}
 
 	// update templates version in config file
 	if err := config.DefaultConfig.SetTemplatesVersion(ghrd.Latest.GetTagName()); err != nil {
-		return err
+		return false, err
 	}
 
 	// after installation create and write checksums to .checksum file
-	return t.writeChecksumFileInDir(dir)
+	return isDelta, t.writeChecksumFileInDir(dir)
+}
+
+// writeUpdatedFiles writes ghrd's release files to dir, preferring a delta
+// update over the full tarball when oldChecksums is non-empty, a manifest
+// is available, and the change is small enough (see shouldUseDelta). It
+// falls back to writeAllFromTarball on a first install, when there's no
+// manifest to diff against, or when applyDelta itself fails.
+func (t *TemplateManager) writeUpdatedFiles(ghrd *updateutils.GHReleaseDownloader, dir string, manifest releaseManifest, oldChecksums map[string]string) (bool, error) {
+	if manifest != nil && len(oldChecksums) > 0 {
+		// .checksum keys are paths under dir, while manifest keys are
+		// relative to the release root
+		relChecksums := make(map[string]string, len(oldChecksums))
+		for path, hash := range oldChecksums {
+			if rel, relErr := filepath.Rel(dir, path); relErr == nil {
+				relChecksums[filepath.ToSlash(rel)] = hash
+			}
+		}
+		adds, mods, dels := planDelta(relChecksums, manifest)
+		if shouldUseDelta(adds, mods, dels, len(relChecksums)) {
+			gologger.Info().Msgf("applying delta template update: %d added, %d modified, %d removed", len(adds), len(mods), len(dels))
+			if err := t.applyDelta(ghrd, dir, manifest, append(adds, mods...), dels); err != nil {
+				gologger.Warning().Msgf("delta template update failed, falling back to full install: %s", err)
+			} else {
+				return true, nil
+			}
+		}
+	}
+	return false, t.writeAllFromTarball(ghrd, dir, manifest)
+}
+
+// writeAllFromTarball downloads ghrd's release and writes its files to
+// dir, applying the shared LFI guard and meta-file handling from
+// getAbsoluteFilePath. It does none of the official-templates-specific
+// config bookkeeping writeTemplatestoDisk does on top of this.
+//
+// If manifest is non-nil, every file is verified against it before being
+// written; manifest is nil when InsecureSkipTemplateVerify is set, or for an
+// additional repository with no PublicKey or with SkipVerify set (see
+// TemplateRepository.resolveManifest), in which case files are written
+// unverified.
+func (t *TemplateManager) writeAllFromTarball(ghrd *updateutils.GHReleaseDownloader, dir string, manifest releaseManifest) error {
+	callbackFunc := func(uri string, f fs.FileInfo, r io.Reader) error {
+		writePath := t.getAbsoluteFilePath(dir, uri, f)
+		if writePath == "" {
+			// skip writing file
+			return nil
+		}
+		bin, err := io.ReadAll(r)
+		if err != nil {
+			// if error occurs, iteration also stops
+			return errorutil.NewWithErr(err).Msgf("failed to read file %s", uri)
+		}
+		if manifest != nil {
+			if err := manifest.verify(uri, bin); err != nil {
+				return err
+			}
+		}
+		return os.WriteFile(writePath, bin, f.Mode())
+	}
+	return ghrd.DownloadSourceWithCallback(!HideProgressBar, callbackFunc)
 }
 
-// getChecksumFromDir returns a map containing checksums (md5 hash) of all yaml files (with .yaml extension)
+// getChecksumFromDir returns a map containing checksums (sha256 hash) of all yaml files (with .yaml extension)
 // if .checksum file does not exist checksums are calculated and returned
 func (t *TemplateManager) getChecksumFromDir(dir string) (map[string]string, error) {
-	checksumFilePath := config.DefaultConfig.GetChecksumFilePath()
-	if fileutil.FileExists(checksumFilePath) {
-		checksums, err := os.ReadFile(checksumFilePath)
+	return t.getChecksumFromPath(config.DefaultConfig.GetChecksumFilePath(), dir)
+}
+
+// getChecksumFromPath is the repo-aware counterpart of getChecksumFromDir:
+// it reads/writes the checksum file at checksumPath instead of always using
+// the default templates directory's .checksum, so additional repositories
+// (see repository.go) can track their own checksum state independently.
+//
+// It understands both the current versioned format
+// ("v2,sha256,<path>,<hex>") and, for one release cycle, the old
+// unversioned md5 format ("<path>,<hex>") so upgrading nuclei doesn't treat
+// every existing template as added on the first run.
+func (t *TemplateManager) getChecksumFromPath(checksumPath, dir string) (map[string]string, error) {
+	if fileutil.FileExists(checksumPath) {
+		checksums, err := os.ReadFile(checksumPath)
 		if err == nil {
 			allChecksums := make(map[string]string)
 			for _, v := range strings.Split(string(checksums), "\n") {
 				v = strings.TrimSpace(v)
+				if v == "" {
+					continue
+				}
 				tmparr := strings.Split(v, ",")
-				if len(tmparr) != 2 {
+				switch len(tmparr) {
+				case 4:
+					// current format: version,algo,path,hex
+					allChecksums[tmparr[2]] = tmparr[3]
+				case 2:
+					// legacy unversioned md5 format: path,hex
+					allChecksums[tmparr[0]] = tmparr[1]
+				default:
 					continue
 				}
-				allChecksums[tmparr[0]] = tmparr[1]
 			}
 			return allChecksums, nil
 		}
@@ -274,31 +401,37 @@ func (t *TemplateManager) getChecksumFromDir(dir string) (map[string]string, err
 // writeChecksumFileInDir creates checksums of all yaml files in given directory
 // and writes them to a file named .checksum
 func (t *TemplateManager) writeChecksumFileInDir(dir string) error {
+	return t.writeChecksumFileAt(config.DefaultConfig.GetChecksumFilePath(), dir)
+}
+
+// writeChecksumFileAt is the repo-aware counterpart of writeChecksumFileInDir.
+func (t *TemplateManager) writeChecksumFileAt(checksumPath, dir string) error {
 	checksumMap, err := t.calculateChecksumMap(dir)
 	if err != nil {
 		return err
 	}
 	var buff bytes.Buffer
 	for k, v := range checksumMap {
-		buff.WriteString(k + "," + v)
+		buff.WriteString(fmt.Sprintf("%s,%s,%s,%s\n", checksumFormatVersion, checksumAlgo, k, v))
 	}
-	return os.WriteFile(config.DefaultConfig.GetChecksumFilePath(), buff.Bytes(), checkSumFilePerm)
+	return os.WriteFile(checksumPath, buff.Bytes(), checkSumFilePerm)
 }
 
-// getChecksumMap returns a map containing checksums (md5 hash) of all yaml files (with .yaml extension)
+// getChecksumMap returns a map containing checksums (sha256 hash) of all yaml files (with .yaml extension)
 func (t *TemplateManager) calculateChecksumMap(dir string) (map[string]string, error) {
 	// getchecksumMap walks given directory `dir` and returns a map containing
-	// checksums (md5 hash) of all yaml files (with .yaml extension) and the
+	// checksums (sha256 hash) of all yaml files (with .yaml extension) and the
 	// format is map[filePath]checksum
 	checksumMap := map[string]string{}
 
 	getChecksum := func(filepath string) (string, error) {
-		// return md5 hash of the file
+		// return sha256 hash of the file
 		bin, err := os.ReadFile(filepath)
 		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("%x", md5.Sum(bin)), nil
+		sum := sha256.Sum256(bin)
+		return hex.EncodeToString(sum[:]), nil
 	}
 
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
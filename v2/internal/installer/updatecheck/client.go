@@ -0,0 +1,85 @@
+// Package updatecheck implements a lightweight client for the
+// ProjectDiscovery-hosted update-check endpoint (the nuclei-updatecheck-api
+// pattern): a single HTTPS GET that returns the latest published
+// nuclei/templates versions, so routine runs don't have to poll the
+// (rate-limited, for unauthenticated callers) GitHub API just to learn
+// nothing changed.
+package updatecheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// DefaultEndpoint is the ProjectDiscovery-hosted update-check endpoint.
+// Self-hosted users can point Client.Endpoint at their own mirror of the
+// same JSON contract instead.
+const DefaultEndpoint = "https://update-check.nuclei.sh/api/v1/versions"
+
+// DefaultTimeout bounds how long a single update-check request is allowed
+// to take before callers fall back to the GitHub API path.
+const DefaultTimeout = 5 * time.Second
+
+// Disabled turns the update-check precheck off entirely, eg. for air-gapped
+// setups that should never have nuclei reach out to ProjectDiscovery's
+// servers on its own.
+var Disabled = false
+
+// Response is the update-check endpoint's JSON response.
+type Response struct {
+	NucleiLatest      string `json:"nuclei_latest"`
+	TemplatesLatest   string `json:"templates_latest"`
+	TemplatesChecksum string `json:"templates_checksum"`
+	IgnoreHash        string `json:"ignore_hash"`
+}
+
+// Client fetches Response from Endpoint, bounded by Timeout. The zero value
+// is ready to use and falls back to DefaultEndpoint/DefaultTimeout.
+type Client struct {
+	Endpoint string
+	Timeout  time.Duration
+}
+
+// Check issues a single GET to c.Endpoint (DefaultEndpoint if unset) and
+// decodes the JSON response. Callers should treat any error - including
+// Disabled being set - as "endpoint unreachable" and fall back to the
+// existing GHReleaseDownloader path rather than failing outright.
+func (c *Client) Check(ctx context.Context) (*Response, error) {
+	if Disabled {
+		return nil, errorutil.New("update-check endpoint is disabled")
+	}
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to build update-check request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to reach update-check endpoint %s", endpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorutil.New("update-check endpoint %s returned status %d", endpoint, resp.StatusCode)
+	}
+
+	var out Response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to parse update-check response from %s", endpoint)
+	}
+	return &out, nil
+}
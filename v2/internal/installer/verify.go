@@ -0,0 +1,193 @@
+package installer
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/projectdiscovery/utils/update"
+)
+
+// InsecureSkipTemplateVerify disables signature/checksum verification of a
+// downloaded release's files. It exists for users pointing TemplateManager
+// at a custom/forked template repository that doesn't publish a signed
+// checksums manifest alongside its releases - leave it false for the
+// official nuclei-templates feed.
+var InsecureSkipTemplateVerify = false
+
+// nucleiTemplatesPublicKey is the pinned ed25519 public key (PKIX,
+// base64-encoded) used to verify the detached signature over checksums.txt
+// published alongside each official nuclei-templates release. Only the
+// nuclei-templates release pipeline holds the matching private key.
+//
+// This is intentionally empty in source: the real key is not checked in.
+// Official release builds inject it at link time, eg.
+//
+//	go build -ldflags "-X $(pkg).nucleiTemplatesPublicKey=<base64 PKIX key>"
+//
+// A source build that doesn't set it has no way to verify a real release
+// signature, so resolveReleaseManifest refuses to even attempt verification
+// against an empty key - that would either reject every genuine release
+// (fail-closed on everyone) or, worse, silently accept checksums.txt
+// unverified if the check were loosened instead.
+var nucleiTemplatesPublicKey = ""
+
+const (
+	// checksumsManifestAsset is the signed list of `<sha256>  <path>` entries
+	// published as a release asset, covering every file in the release archive.
+	checksumsManifestAsset = "checksums.txt"
+	// checksumsSignatureAsset is the detached ed25519 signature over
+	// checksumsManifestAsset, published alongside it.
+	checksumsSignatureAsset = "checksums.txt.sig"
+)
+
+// releaseManifest maps a release archive's file paths (relative to its root
+// directory) to their expected sha256 hex digest, as published in a
+// release's signed checksums.txt.
+type releaseManifest map[string]string
+
+// resolveReleaseManifest fetches and verifies ghrd's signed checksums
+// manifest, unless verification has been explicitly disabled via
+// InsecureSkipTemplateVerify. A verification failure is always fatal: it is
+// never safe to silently fall back to writing unverified templates.
+func resolveReleaseManifest(ghrd *update.GHReleaseDownloader) (releaseManifest, error) {
+	if nucleiTemplatesPublicKey == "" {
+		if InsecureSkipTemplateVerify {
+			return nil, nil
+		}
+		return nil, errorutil.New("this build has no template signing key compiled in, so signed releases cannot be verified (pass --insecure-skip-template-verify to download anyway, or use an official release build)")
+	}
+	return resolveReleaseManifestWithKey(ghrd, nucleiTemplatesPublicKey)
+}
+
+// resolveReleaseManifestWithKey is resolveReleaseManifest, but verifying
+// against publicKey instead of the pinned official one - used for additional
+// template repositories (see TemplateRepository.PublicKey), which are never
+// signed by the official nuclei-templates key.
+func resolveReleaseManifestWithKey(ghrd *update.GHReleaseDownloader, publicKey string) (releaseManifest, error) {
+	if InsecureSkipTemplateVerify {
+		return nil, nil
+	}
+	manifest, signature, err := fetchSignedManifest(ghrd)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to fetch signed checksums manifest (use --insecure-skip-template-verify to bypass for custom repositories)")
+	}
+	pubKey, err := parseEd25519PublicKey(publicKey)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to parse pinned template signing key")
+	}
+	if !ed25519.Verify(pubKey, manifest, signature) {
+		return nil, errorutil.New("signature verification failed for %s: release may be tampered with", checksumsManifestAsset)
+	}
+	return parseReleaseManifest(manifest), nil
+}
+
+// verify checks bin's sha256 digest against m's entry for uri (with the
+// release archive's root directory component stripped, matching
+// getAbsoluteFilePath's own stripping), failing loudly on a mismatch or a
+// missing entry rather than letting an unverified file reach disk.
+func (m releaseManifest) verify(uri string, bin []byte) error {
+	return m.verifyPath(manifestKey(uri), uri, bin)
+}
+
+// verifyPath is the path-keyed counterpart of verify: path must already be
+// relative to the manifest root (no uri root-directory stripping), which is
+// what applyDelta's raw-fetched files are keyed by. label is only used for
+// the error message, since it may still be the original uri.
+func (m releaseManifest) verifyPath(path, label string, bin []byte) error {
+	expected, ok := m[path]
+	if !ok {
+		return errorutil.New("no checksum entry for %s in signed manifest: refusing to write unverified file", label)
+	}
+	sum := sha256.Sum256(bin)
+	if hex.EncodeToString(sum[:]) != expected {
+		return errorutil.New("checksum mismatch for %s: release may be corrupted or tampered with", label)
+	}
+	return nil
+}
+
+// manifestKey strips a release uri's leading root-directory component
+// (eg. "projectdiscovery-nuclei-templates-<hash>/") the same way
+// getAbsoluteFilePath does, so it lines up with the relative paths
+// checksums.txt is generated from.
+func manifestKey(uri string) string {
+	if index := strings.Index(uri, "/"); index != -1 {
+		return uri[index+1:]
+	}
+	return uri
+}
+
+// fetchSignedManifest downloads checksums.txt and its detached signature
+// from ghrd's release assets.
+func fetchSignedManifest(ghrd *update.GHReleaseDownloader) (manifest []byte, signature []byte, err error) {
+	manifest, err = fetchReleaseAsset(ghrd, checksumsManifestAsset)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err = fetchReleaseAsset(ghrd, checksumsSignatureAsset)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, signature, nil
+}
+
+// fetchReleaseAsset downloads the named asset from ghrd's release.
+func fetchReleaseAsset(ghrd *update.GHReleaseDownloader, name string) ([]byte, error) {
+	for _, asset := range ghrd.Latest.Assets {
+		if asset.GetName() != name {
+			continue
+		}
+		resp, err := http.Get(asset.GetBrowserDownloadURL())
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errorutil.New("unexpected status %d fetching release asset %s", resp.StatusCode, name)
+		}
+		return io.ReadAll(resp.Body)
+	}
+	return nil, errorutil.New("release asset %q not found", name)
+}
+
+// parseReleaseManifest parses checksums.txt's `<sha256 hex>  <path>` lines
+// (the format `sha256sum` produces) into a releaseManifest.
+func parseReleaseManifest(data []byte) releaseManifest {
+	manifest := make(releaseManifest)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		manifest[fields[1]] = fields[0]
+	}
+	return manifest
+}
+
+// parseEd25519PublicKey decodes a base64-encoded PKIX public key and
+// asserts it is ed25519.
+func parseEd25519PublicKey(b64 string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, errorutil.New("pinned template signing key is not ed25519")
+	}
+	return key, nil
+}
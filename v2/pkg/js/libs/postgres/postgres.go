@@ -1,19 +1,57 @@
 package postgres
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/go-pg/pg"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/praetorian-inc/fingerprintx/pkg/plugins"
 	postgres "github.com/praetorian-inc/fingerprintx/pkg/plugins/services/postgresql"
 	"github.com/projectdiscovery/nuclei/v2/pkg/js/scripts/utils"
 )
 
+// SSL modes accepted by Options.SSLMode, mirroring libpq's sslmode values.
+const (
+	SSLModeDisable    = "disable"
+	SSLModeAllow      = "allow"
+	SSLModePrefer     = "prefer"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// Options contains TLS and timeout settings used by ConnectWithOptions and
+// ExecuteQueryWithOptions to reach Postgres servers that require (or
+// mandate) TLS, such as managed offerings like RDS or Cloud SQL.
+type Options struct {
+	// SSLMode is one of disable/allow/prefer/require/verify-ca/verify-full.
+	// An empty value behaves like disable.
+	SSLMode string
+	// RootCAPath, if set, is used to verify the server certificate instead
+	// of the system trust store. Required for verify-ca/verify-full.
+	RootCAPath string
+	// ClientCertPath/ClientKeyPath, if both set, enable client certificate
+	// authentication.
+	ClientCertPath string
+	ClientKeyPath  string
+	// ServerName overrides the hostname used for certificate verification.
+	ServerName string
+	// ConnectTimeout bounds the initial connection. Defaults to 10s for
+	// ConnectWithOptions; ExecuteQueryWithOptions sets no connect timeout
+	// when it is zero.
+	ConnectTimeout time.Duration
+	// StatementTimeout bounds query execution. Zero means no timeout.
+	StatementTimeout time.Duration
+}
+
 // Client is a client for Postgres database.
 //
 // Internally client uses go-pg/pg driver.
@@ -36,13 +74,43 @@ func (c *Client) IsPostgres(host string, port int) (bool, error) {
 
 	plugin := &postgres.POSTGRESPlugin{}
 	service, err := plugin.Run(conn, timeout, plugins.Target{Host: host})
-	if err != nil {
+	if err == nil && service != nil {
+		return true, nil
+	}
+
+	// The plugin above sends a plaintext startup message, which pg_hba.conf
+	// may reject outright (eg. "hostssl"-only entries) before it ever gets a
+	// chance to identify the server. Fall back to a raw SSLRequest
+	// negotiation on a fresh connection, which Postgres answers to
+	// regardless of what pg_hba.conf says about plaintext auth.
+	sslConn, sslErr := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), timeout)
+	if sslErr != nil {
+		return false, sslErr
+	}
+	defer sslConn.Close()
+
+	return probeSSLRequest(sslConn, timeout)
+}
+
+// sslRequestPacket is the Postgres wire protocol's SSLRequest startup
+// packet: a 4-byte length (8) followed by the reserved magic code 80877103.
+var sslRequestPacket = []byte{0x00, 0x00, 0x00, 0x08, 0x04, 0xd2, 0x16, 0x2f}
+
+// probeSSLRequest sends an SSLRequest packet and reports whether the peer
+// replied with Postgres's single-byte 'S' (will upgrade) or 'N' (won't)
+// answer, which only a Postgres server speaks.
+func probeSSLRequest(conn net.Conn, timeout time.Duration) (bool, error) {
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
 		return false, err
 	}
-	if service == nil {
-		return false, nil
+	if _, err := conn.Write(sslRequestPacket); err != nil {
+		return false, err
 	}
-	return true, nil
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return false, err
+	}
+	return resp[0] == 'S' || resp[0] == 'N', nil
 }
 
 // Connect connects to Postgres database using given credentials.
@@ -52,19 +120,57 @@ func (c *Client) IsPostgres(host string, port int) (bool, error) {
 //
 // The connection is closed after the function returns.
 func (c *Client) Connect(host string, port int, username, password string) (bool, error) {
-	return connect(host, port, username, password, "postgres")
+	return connect(host, port, username, password, "postgres", Options{})
+}
+
+// ConnectWithOptions connects to Postgres database using given credentials
+// and TLS/timeout Options, for servers that require encrypted connections.
+//
+// If connection is successful, it returns true.
+// If connection is unsuccessful, it returns false and error.
+//
+// The connection is closed after the function returns.
+func (c *Client) ConnectWithOptions(host string, port int, username, password, dbName string, options Options) (bool, error) {
+	return connect(host, port, username, password, dbName, options)
 }
 
 // ExecuteQuery connects to Postgres database using given credentials and database name.
 // and executes a query on the db.
 func (c *Client) ExecuteQuery(host string, port int, username, password, dbName, query string) (string, error) {
+	return executeQuery(host, port, username, password, dbName, query, Options{})
+}
+
+// ExecuteQueryWithOptions connects to Postgres database using given
+// credentials, database name and TLS/timeout Options, and executes a query
+// on the db.
+func (c *Client) ExecuteQueryWithOptions(host string, port int, username, password, dbName, query string, options Options) (string, error) {
+	return executeQuery(host, port, username, password, dbName, query, options)
+}
+
+func executeQuery(host string, port int, username, password, dbName, query string, options Options) (string, error) {
 	target := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", username, password, target, dbName)
+	sslMode, cleanup, err := sqlSSLMode(options)
+	if err != nil {
+		return "", err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s", username, password, target, dbName, sslMode)
+	if options.ConnectTimeout > 0 {
+		connStr += fmt.Sprintf("&connect_timeout=%d", int(options.ConnectTimeout.Seconds()))
+	}
+	if options.StatementTimeout > 0 {
+		connStr += fmt.Sprintf("&statement_timeout=%d", options.StatementTimeout.Milliseconds())
+	}
+
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return "", err
 	}
+	defer db.Close()
 
 	rows, err := db.Query(query)
 	if err != nil {
@@ -84,21 +190,68 @@ func (c *Client) ExecuteQuery(host string, port int, username, password, dbName,
 //
 // The connection is closed after the function returns.
 func (c *Client) ConnectWithDB(host string, port int, username, password, dbName string) (bool, error) {
-	return connect(host, port, username, password, dbName)
+	return connect(host, port, username, password, dbName, Options{})
 }
 
-func connect(host string, port int, username, password, dbName string) (bool, error) {
+func connect(host string, port int, username, password, dbName string, options Options) (bool, error) {
 	if host == "" || port <= 0 {
 		return false, fmt.Errorf("invalid host or port")
 	}
 	target := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 
-	db := pg.Connect(&pg.Options{
-		Addr:     target,
-		User:     username,
-		Password: password,
-		Database: dbName,
-	})
+	tlsConfig, err := buildTLSConfig(options)
+	if err != nil {
+		return false, err
+	}
+
+	connectTimeout := options.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = 10 * time.Second
+	}
+
+	pgOptions := &pg.Options{
+		Addr:        target,
+		User:        username,
+		Password:    password,
+		Database:    dbName,
+		TLSConfig:   tlsConfig,
+		DialTimeout: connectTimeout,
+	}
+
+	ok, err := exec(pgOptions, options.StatementTimeout)
+	if ok || err != nil || !isFallbackSSLMode(options.SSLMode) || tlsConfig == nil {
+		return ok, err
+	}
+
+	// go-pg's TLSConfig is a hard requirement with no libpq-style graceful
+	// downgrade, so "allow"/"prefer" are implemented here as an explicit
+	// plaintext retry after the TLS attempt fails, matching libpq's
+	// documented fallback behaviour for those two modes.
+	plaintextOptions := *pgOptions
+	plaintextOptions.TLSConfig = nil
+	return exec(&plaintextOptions, options.StatementTimeout)
+}
+
+// isFallbackSSLMode reports whether sslMode is one of libpq's two modes that
+// tolerate the peer not supporting the attempted encryption state, instead
+// of treating a failed TLS handshake as a hard connection failure.
+func isFallbackSSLMode(sslMode string) bool {
+	return sslMode == SSLModeAllow || sslMode == SSLModePrefer
+}
+
+// exec opens a connection with pgOptions and runs a trivial query against
+// it, translating go-pg errors into the (connected bool, error) shape the
+// rest of this package expects: a hard error for transport-level failures,
+// false/nil for an auth/protocol rejection that still proves a server is
+// there.
+func exec(pgOptions *pg.Options, statementTimeout time.Duration) (bool, error) {
+	db := pg.Connect(pgOptions)
+	defer db.Close()
+
+	if statementTimeout > 0 {
+		db = db.WithTimeout(statementTimeout)
+	}
+
 	_, err := db.Exec("select 1")
 	if err != nil {
 		switch true {
@@ -117,3 +270,68 @@ func connect(host string, port int, username, password, dbName string) (bool, er
 	}
 	return true, nil
 }
+
+// buildTLSConfig turns Options into a *tls.Config for the go-pg connect
+// path, or nil when TLS isn't requested (SSLMode unset or "disable").
+func buildTLSConfig(options Options) (*tls.Config, error) {
+	if options.SSLMode == "" || options.SSLMode == SSLModeDisable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: options.ServerName}
+
+	if options.SSLMode == SSLModeRequire {
+		// require only asks for encryption, not certificate validation.
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if options.RootCAPath != "" {
+		caPEM, err := os.ReadFile(options.RootCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read root CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("could not parse root CA %q", options.RootCAPath)
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.InsecureSkipVerify = false
+	}
+
+	if options.ClientCertPath != "" && options.ClientKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(options.ClientCertPath, options.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// sqlSSLMode returns the sslmode value to use in a lib/pq connection
+// string, along with an optional cleanup func that must be called once the
+// connection is no longer needed. Plain sslmode values (disable/allow/
+// prefer/require/verify-ca/verify-full) are passed straight through to
+// lib/pq; a custom root CA or client certificate requires building our own
+// *tls.Config and registering it under a synthetic name, since lib/pq's
+// connection string can't express those directly.
+func sqlSSLMode(options Options) (string, func(), error) {
+	if options.RootCAPath == "" && options.ClientCertPath == "" {
+		if options.SSLMode == "" {
+			return SSLModeDisable, nil, nil
+		}
+		return options.SSLMode, nil, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(options)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := fmt.Sprintf("nuclei-postgres-%p", tlsConfig)
+	if err := pq.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", nil, fmt.Errorf("could not register tls config: %w", err)
+	}
+	return name, func() { pq.DeregisterTLSConfig(name) }, nil
+}
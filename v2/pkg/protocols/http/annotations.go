@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// Annotation keys recognised on the leading "@key: value" lines of a raw
+// request, before the actual HTTP request line.
+const (
+	annotationTimeout = "timeout"
+	annotationHost    = "host"
+	annotationProxy   = "proxy"
+)
+
+// proxyContextKey is the context key used to attach a per-request proxy
+// transport to a retryablehttp.Request; the executor checks for it before
+// falling back to the client's globally configured proxy.
+type proxyContextKey struct{}
+
+// ProxyTransportFromContext returns the per-request proxy transport
+// attached by a @proxy annotation, if any.
+func ProxyTransportFromContext(ctx context.Context) (*http.Transport, bool) {
+	transport, ok := ctx.Value(proxyContextKey{}).(*http.Transport)
+	return transport, ok
+}
+
+var (
+	proxyTransportCache   = make(map[string]*http.Transport)
+	proxyTransportCacheMu sync.Mutex
+)
+
+// allowedProxySchemes are the proxy URL schemes accepted by the @proxy
+// annotation.
+var allowedProxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+	"socks4a": true,
+}
+
+// parseAnnotations scans the leading "@key: value" lines of a raw request
+// and applies any recognised annotations to req, returning the (possibly
+// replaced) request, a cancel function the caller should defer (a no-op if
+// no @timeout annotation was present), and whether any annotation was found.
+func (request *Request) parseAnnotations(rawRequest string, req *retryablehttp.Request) (*retryablehttp.Request, context.CancelFunc, bool) {
+	cancel := func() {}
+	found := false
+
+	for _, line := range strings.Split(rawRequest, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(line, "@") {
+			break
+		}
+		key, value, ok := splitAnnotation(line)
+		if !ok {
+			continue
+		}
+		found = true
+
+		switch strings.ToLower(key) {
+		case annotationTimeout:
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				gologger.Warning().Msgf("could not apply @timeout annotation %q: %s", value, err)
+				continue
+			}
+			ctx, cancelFunc := context.WithTimeout(req.Context(), duration)
+			req.Request = req.Request.WithContext(ctx)
+			cancel = cancelFunc
+		case annotationHost:
+			req.Host = value
+			req.Header.Set("Host", value)
+		case annotationProxy:
+			transport, err := proxyTransportFor(value)
+			if err != nil {
+				gologger.Warning().Msgf("could not apply @proxy annotation %q: %s", value, err)
+				continue
+			}
+			req.Request = req.Request.WithContext(context.WithValue(req.Context(), proxyContextKey{}, transport))
+		}
+	}
+	return req, cancel, found
+}
+
+// splitAnnotation parses a "@key: value" or "@key:value" line.
+func splitAnnotation(line string) (string, string, bool) {
+	line = strings.TrimPrefix(line, "@")
+	idx := strings.Index(line, ":")
+	if idx == -1 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:]), true
+}
+
+// proxyTransportFor returns a cached *http.Transport configured to dial
+// through rawProxyURL, building and caching a new one on first use. http(s)
+// proxies are handled via http.ProxyURL; socks5/socks5h/socks4a build a
+// golang.org/x/net/proxy dialer instead, since net/http has no native SOCKS
+// support.
+func proxyTransportFor(rawProxyURL string) (*http.Transport, error) {
+	proxyTransportCacheMu.Lock()
+	defer proxyTransportCacheMu.Unlock()
+
+	if transport, ok := proxyTransportCache[rawProxyURL]; ok {
+		return transport, nil
+	}
+
+	parsed, err := url.Parse(rawProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	if !allowedProxySchemes[parsed.Scheme] {
+		return nil, fmt.Errorf("unsupported proxy scheme %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("proxy url is missing host:port")
+	}
+
+	var transport *http.Transport
+	switch parsed.Scheme {
+	case "http", "https":
+		transport = &http.Transport{Proxy: http.ProxyURL(parsed)}
+	default:
+		// socks5/socks5h/socks4a all route through the same SOCKS5 dialer;
+		// we don't distinguish them further since golang.org/x/net/proxy
+		// only speaks SOCKS5.
+		var auth *proxy.Auth
+		if parsed.User != nil {
+			auth = &proxy.Auth{User: parsed.User.Username()}
+			if password, ok := parsed.User.Password(); ok {
+				auth.Password = password
+			}
+		}
+		dialer, dialerErr := proxy.SOCKS5("tcp", parsed.Host, auth, proxy.Direct)
+		if dialerErr != nil {
+			return nil, fmt.Errorf("could not build socks dialer: %w", dialerErr)
+		}
+		transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+		}
+	}
+
+	proxyTransportCache[rawProxyURL] = transport
+	return transport, nil
+}
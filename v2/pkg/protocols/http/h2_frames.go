@@ -0,0 +1,265 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/expressions"
+)
+
+var (
+	errNoRawRequestLine        = errors.New("raw request has no request line")
+	errMalformedRawRequestLine = errors.New("malformed raw request line")
+)
+
+// DefaultH2ContinuationSplit bounds how many encoded header bytes go into
+// each HEADERS/CONTINUATION frame when a template doesn't set one
+// explicitly.
+const DefaultH2ContinuationSplit = 16384
+
+// h2PseudoHeaderOrder is the conventional wire order for HTTP/2 request
+// pseudo-headers (RFC 7540 section 8.1.2.1), and the order send encodes
+// them in; a template cannot change this order.
+var h2PseudoHeaderOrder = []string{":method", ":scheme", ":authority", ":path"}
+
+// h2HeaderField is a single name/value pair preserved in the exact order (and
+// with the exact duplication) it appeared on the wire in a raw request.
+type h2HeaderField struct {
+	Name  string
+	Value string
+}
+
+// h2HeaderFields is an ordered list of h2HeaderField, used in place of
+// map[string]string so raw templates can deliberately send duplicate header
+// names or a non-canonical header order - a map would silently collapse
+// duplicates and randomize order before HPACK encoding, defeating the whole
+// point of frame-level control.
+type h2HeaderFields []h2HeaderField
+
+// h2FrameRequest describes a single, frame-level HTTP/2 request built from
+// an Unsafe && HTTP2 raw template. Instead of handing off to
+// golang.org/x/net/http2's Transport (which always produces a
+// spec-compliant stream), it drives http2.Framer directly: headers keep
+// their raw order and duplicates, and the header block is split across
+// CONTINUATION frames per ContinuationSplit - building blocks for h2c
+// smuggling, HPACK header injection and CONTINUATION-flood style tests.
+// makeH2FrameRequestFromRaw always fills all four pseudo-headers from the
+// request line and target URL, always sets EndHeaders, and sets EndStream
+// only when there is no body; no template field omits or overrides a
+// pseudo-header or chooses these flags.
+type h2FrameRequest struct {
+	PseudoHeaders     map[string]string
+	Headers           h2HeaderFields
+	Body              []byte
+	EndStream         bool
+	EndHeaders        bool
+	ContinuationSplit int
+	// Streams mirrors Request.Threads for HTTP/1 pipelining: it's the
+	// number of concurrent stream IDs this same request is replayed on over
+	// the one connection, for single-packet-style race/multiplexing tests.
+	Streams int
+}
+
+// makeH2FrameRequestFromRaw parses a raw Unsafe&&HTTP2 template request,
+// dials an HTTP/2 connection to baseURL (TLS with ALPN "h2", unless the
+// template targets a plaintext h2c endpoint), and replays the request's
+// pseudo-headers/headers/body across h2FrameRequest.Streams concurrent
+// stream IDs on that single connection.
+func (r *requestGenerator) makeH2FrameRequestFromRaw(ctx context.Context, baseURL, data string, values map[string]interface{}) (*generatedRequest, error) {
+	rawRequest, err := expressions.Evaluate(data, values)
+	if err != nil {
+		return nil, err
+	}
+
+	method, path, headers, body, err := parseRawH2Request(rawRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &h2FrameRequest{
+		PseudoHeaders: map[string]string{
+			":method":    method,
+			":scheme":    parsedURL.Scheme,
+			":authority": parsedURL.Host,
+			":path":      path,
+		},
+		Headers:           headers,
+		Body:              []byte(body),
+		EndStream:         len(body) == 0,
+		EndHeaders:        true,
+		ContinuationSplit: r.request.ContinuationSplit,
+		Streams:           r.request.Streams,
+	}
+
+	conn, err := dialH2(ctx, parsedURL.Host, parsedURL.Scheme == "http", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	streamIDs, err := frame.send(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &generatedRequest{
+		original:    r.request,
+		h2Frame:     frame,
+		h2Conn:      conn,
+		h2StreamIDs: streamIDs,
+	}, nil
+}
+
+// parseRawH2Request pulls the method, path, headers and body out of a raw
+// request template; unlike HTTP/1.1's raw.Parse, the request-line's
+// protocol token and the Host header are ignored entirely since HTTP/2
+// carries that information in the :authority/:scheme pseudo-headers
+// instead.
+func parseRawH2Request(rawRequest string) (method, path string, headers h2HeaderFields, body string, err error) {
+	parts := strings.SplitN(rawRequest, "\r\n\r\n", 2)
+	if len(parts) == 2 {
+		body = parts[1]
+	}
+
+	lines := strings.Split(parts[0], "\n")
+	if len(lines) == 0 {
+		return "", "", nil, "", errNoRawRequestLine
+	}
+
+	requestLine := strings.Fields(strings.TrimRight(lines[0], "\r"))
+	if len(requestLine) < 2 {
+		return "", "", nil, "", errMalformedRawRequestLine
+	}
+	method, path = requestLine[0], requestLine[1]
+
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, "Host") {
+			continue
+		}
+		headers = append(headers, h2HeaderField{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return method, path, headers, body, nil
+}
+
+// dialH2 opens a connection to addr negotiated for HTTP/2. Over TLS it
+// advertises "h2" via ALPN; for h2c targets the connection is a plain TCP
+// dial and the peer is expected to already speak HTTP/2 by "prior
+// knowledge" (no Upgrade handshake), which is how h2c-smuggling proxies are
+// normally tested.
+func dialH2(ctx context.Context, addr string, h2c bool, tlsConfig *tls.Config) (net.Conn, error) {
+	if h2c {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	cfg := tlsConfig.Clone()
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg.NextProtos = []string{"h2"}
+	dialer := &tls.Dialer{Config: cfg}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// send writes the HTTP/2 client preface, an empty initial SETTINGS frame,
+// and then one HEADERS (+ CONTINUATION, as needed) sequence per concurrent
+// stream, reusing the same connection the way a legitimate h2 client
+// multiplexes requests - but under full template control.
+func (h *h2FrameRequest) send(conn net.Conn) ([]int, error) {
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return nil, err
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(); err != nil {
+		return nil, err
+	}
+
+	streams := h.Streams
+	if streams <= 0 {
+		streams = 1
+	}
+
+	var headerBuf bytes.Buffer
+	encoder := hpack.NewEncoder(&headerBuf)
+	for _, name := range h2PseudoHeaderOrder {
+		if value, ok := h.PseudoHeaders[name]; ok {
+			_ = encoder.WriteField(hpack.HeaderField{Name: name, Value: value})
+		}
+	}
+	for _, field := range h.Headers {
+		_ = encoder.WriteField(hpack.HeaderField{Name: strings.ToLower(field.Name), Value: field.Value})
+	}
+	encoded := headerBuf.Bytes()
+
+	chunkSize := h.ContinuationSplit
+	if chunkSize <= 0 {
+		chunkSize = DefaultH2ContinuationSplit
+	}
+
+	streamIDs := make([]int, 0, streams)
+	for i := 0; i < streams; i++ {
+		streamID := uint32(2*i + 1) // client-initiated streams are odd
+		if err := writeHeadersWithContinuation(framer, streamID, encoded, chunkSize, h.EndStream, h.EndHeaders); err != nil {
+			return nil, err
+		}
+		if len(h.Body) > 0 {
+			if err := framer.WriteData(streamID, h.EndStream, h.Body); err != nil {
+				return nil, err
+			}
+		}
+		streamIDs = append(streamIDs, int(streamID))
+	}
+	return streamIDs, nil
+}
+
+// writeHeadersWithContinuation writes encoded as a HEADERS frame followed
+// by as many CONTINUATION frames as needed to stay under chunkSize bytes
+// per frame; endHeaders only applies to the final frame in the sequence.
+func writeHeadersWithContinuation(framer *http2.Framer, streamID uint32, encoded []byte, chunkSize int, endStream, endHeaders bool) error {
+	first := encoded
+	var rest []byte
+	if len(encoded) > chunkSize {
+		first, rest = encoded[:chunkSize], encoded[chunkSize:]
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: first,
+		EndStream:     endStream,
+		EndHeaders:    endHeaders && len(rest) == 0,
+	}); err != nil {
+		return err
+	}
+
+	for len(rest) > 0 {
+		chunk := rest
+		if len(chunk) > chunkSize {
+			chunk = rest[:chunkSize]
+		}
+		rest = rest[len(chunk):]
+		if err := framer.WriteContinuation(streamID, endHeaders && len(rest) == 0, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
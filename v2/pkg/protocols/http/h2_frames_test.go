@@ -0,0 +1,40 @@
+package http
+
+import "testing"
+
+// TestParseRawH2RequestPreservesDuplicateAndOrderedHeaders guards against
+// regressing to a map[string]string, which silently drops duplicate header
+// names and randomizes wire order before HPACK encoding.
+func TestParseRawH2RequestPreservesDuplicateAndOrderedHeaders(t *testing.T) {
+	raw := "GET /path HTTP/2\r\n" +
+		"Host: example.com\r\n" +
+		"X-Foo: one\r\n" +
+		"X-Bar: two\r\n" +
+		"X-Foo: three\r\n" +
+		"\r\n"
+
+	method, path, headers, body, err := parseRawH2Request(raw)
+	if err != nil {
+		t.Fatalf("parseRawH2Request() error = %s", err)
+	}
+	if method != "GET" || path != "/path" {
+		t.Fatalf("got method=%q path=%q, want GET /path", method, path)
+	}
+	if body != "" {
+		t.Fatalf("got body=%q, want empty", body)
+	}
+
+	want := h2HeaderFields{
+		{Name: "X-Foo", Value: "one"},
+		{Name: "X-Bar", Value: "two"},
+		{Name: "X-Foo", Value: "three"},
+	}
+	if len(headers) != len(want) {
+		t.Fatalf("got %d headers, want %d: %+v", len(headers), len(want), headers)
+	}
+	for i, field := range headers {
+		if field != want[i] {
+			t.Errorf("header[%d] = %+v, want %+v", i, field, want[i])
+		}
+	}
+}
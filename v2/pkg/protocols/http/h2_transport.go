@@ -0,0 +1,42 @@
+package http
+
+import (
+	"crypto/tls"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// h2TransportCache caches one *http2.Transport per target host, so
+// requests to the same host reuse the same underlying HTTP/2 connection
+// (and its stream multiplexing) instead of paying a new handshake per
+// request.
+var (
+	h2TransportCache   = make(map[string]*http2.Transport)
+	h2TransportCacheMu sync.Mutex
+)
+
+// h2TransportFor returns the cached *http2.Transport for host, building one
+// on first use. tlsConfig is cloned (or a default one built) so each
+// transport owns its own config rather than sharing a mutable pointer.
+func h2TransportFor(host string, tlsConfig *tls.Config) *http2.Transport {
+	h2TransportCacheMu.Lock()
+	defer h2TransportCacheMu.Unlock()
+
+	if transport, ok := h2TransportCache[host]; ok {
+		return transport
+	}
+
+	cfg := tlsConfig.Clone()
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg.NextProtos = []string{"h2"}
+
+	transport := &http2.Transport{
+		TLSClientConfig: cfg,
+	}
+
+	h2TransportCache[host] = transport
+	return transport
+}
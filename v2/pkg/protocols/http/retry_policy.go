@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// Error classes accepted by RetryPolicy.RetryOnErrors.
+const (
+	RetryOnConnectionReset = "connection_reset"
+	RetryOnTimeout         = "timeout"
+	RetryOnTLSHandshake    = "tls_handshake"
+)
+
+// Defaults used when a RetryPolicy is set but doesn't specify a backoff
+// shape.
+const (
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultMultiplier     = 2.0
+)
+
+// RetryPolicy lets a single HTTP request opt into its own retry/backoff
+// behavior instead of the client's globally-configured retry count, for
+// templates that target known-flaky endpoints without making every other
+// request in the scan retry just as aggressively. Unset (MaxRetries: 0)
+// matches the client's own zero-retries-by-default stance, chosen to avoid
+// amplifying failures across a large scan.
+type RetryPolicy struct {
+	MaxRetries        int           `yaml:"max_retries,omitempty"`
+	InitialBackoff    time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff        time.Duration `yaml:"max_backoff,omitempty"`
+	Multiplier        float64       `yaml:"multiplier,omitempty"`
+	Jitter            float64       `yaml:"jitter,omitempty"`
+	RetryOnStatus     []int         `yaml:"retry_on_status,omitempty"`
+	RetryOnErrors     []string      `yaml:"retry_on_errors,omitempty"`
+	RespectRetryAfter bool          `yaml:"respect_retry_after,omitempty"`
+}
+
+type (
+	checkRetryContextKey struct{}
+	backoffContextKey    struct{}
+	retryCountContextKey struct{}
+)
+
+// CheckRetryFromContext returns the per-request CheckRetry attached by a
+// RetryPolicy, if any; the shared retryablehttp client should prefer this
+// over its globally-configured one when present.
+func CheckRetryFromContext(ctx context.Context) (retryablehttp.CheckRetry, bool) {
+	checkRetry, ok := ctx.Value(checkRetryContextKey{}).(retryablehttp.CheckRetry)
+	return checkRetry, ok
+}
+
+// BackoffFromContext returns the per-request Backoff attached by a
+// RetryPolicy, if any.
+func BackoffFromContext(ctx context.Context) (retryablehttp.Backoff, bool) {
+	backoff, ok := ctx.Value(backoffContextKey{}).(retryablehttp.Backoff)
+	return backoff, ok
+}
+
+// RetryCountFromContext returns how many retries the request actually used,
+// so it can be surfaced in the response metadata (eg. as a
+// "retry_count" DSL field) for templates that assert on it.
+func RetryCountFromContext(ctx context.Context) (int, bool) {
+	count, ok := ctx.Value(retryCountContextKey{}).(*int)
+	if !ok {
+		return 0, false
+	}
+	return *count, true
+}
+
+// applyRetryPolicy attaches req.original's RetryPolicy (if set) to req's
+// context as a CheckRetry/Backoff pair plus a retry counter, so the shared
+// client can honor it for this request alone.
+func (r *requestGenerator) applyRetryPolicy(req *retryablehttp.Request) {
+	policy := r.request.RetryPolicy
+	if policy == nil {
+		return
+	}
+
+	retryCount := new(int)
+	ctx := req.Context()
+	ctx = context.WithValue(ctx, checkRetryContextKey{}, buildCheckRetry(policy, retryCount))
+	ctx = context.WithValue(ctx, backoffContextKey{}, buildBackoff(policy))
+	ctx = context.WithValue(ctx, retryCountContextKey{}, retryCount)
+	req.Request = req.Request.WithContext(ctx)
+}
+
+// buildCheckRetry returns a retryablehttp.CheckRetry that retries up to
+// policy.MaxRetries times, only for status codes in policy.RetryOnStatus or
+// errors classified into policy.RetryOnErrors, incrementing retryCount on
+// every retry so it can be read back afterwards.
+func buildCheckRetry(policy *RetryPolicy, retryCount *int) retryablehttp.CheckRetry {
+	statuses := make(map[int]bool, len(policy.RetryOnStatus))
+	for _, code := range policy.RetryOnStatus {
+		statuses[code] = true
+	}
+	errorClasses := make(map[string]bool, len(policy.RetryOnErrors))
+	for _, class := range policy.RetryOnErrors {
+		errorClasses[strings.ToLower(class)] = true
+	}
+
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if *retryCount >= policy.MaxRetries {
+			return false, nil
+		}
+
+		var shouldRetry bool
+		switch {
+		case err != nil:
+			shouldRetry = classifyRetryableError(err, errorClasses)
+		case resp != nil:
+			shouldRetry = statuses[resp.StatusCode]
+		}
+
+		if shouldRetry {
+			*retryCount++
+		}
+		return shouldRetry, nil
+	}
+}
+
+// classifyRetryableError reports whether err matches one of the requested
+// error classes. Matching is done on the error text, since the underlying
+// transport errors aren't always exposed as typed sentinel errors.
+func classifyRetryableError(err error, classes map[string]bool) bool {
+	if len(classes) == 0 {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	if classes[RetryOnConnectionReset] && (strings.Contains(msg, "connection reset") || strings.Contains(msg, "econnreset")) {
+		return true
+	}
+	if classes[RetryOnTimeout] && (strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded")) {
+		return true
+	}
+	if classes[RetryOnTLSHandshake] && (strings.Contains(msg, "tls") || strings.Contains(msg, "handshake")) {
+		return true
+	}
+	return false
+}
+
+// buildBackoff returns a retryablehttp.Backoff implementing an exponential
+// backoff with optional jitter, honoring policy.RespectRetryAfter when the
+// server sends a Retry-After header.
+func buildBackoff(policy *RetryPolicy) retryablehttp.Backoff {
+	initial := policy.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = DefaultMultiplier
+	}
+
+	return func(_, _ time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		if policy.RespectRetryAfter && resp != nil {
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+				if seconds, err := strconv.Atoi(retryAfter); err == nil {
+					return time.Duration(seconds) * time.Second
+				}
+			}
+		}
+
+		backoff := float64(initial) * math.Pow(multiplier, float64(attemptNum))
+		if backoff > float64(maxBackoff) {
+			backoff = float64(maxBackoff)
+		}
+		if policy.Jitter > 0 {
+			backoff += backoff * policy.Jitter * rand.Float64()
+		}
+		return time.Duration(backoff)
+	}
+}
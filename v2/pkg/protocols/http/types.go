@@ -0,0 +1,94 @@
+package http
+
+import (
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/generators"
+)
+
+// requestGenerator turns a template's Request into one or more
+// *generatedRequest values, threading payload/interactsh state across the
+// calls needed to exhaust every combination of Request.Raw/Request.Path and
+// its payloads.
+type requestGenerator struct {
+	request *Request
+	options *protocols.ExecutorOptions
+
+	payloadIterator *generators.Iterator
+	interactshURLs  []string
+}
+
+// HTTPMethodTypeHolder holds the HTTP method configured on a Request,
+// allowing it to be expressed either as a plain string or (in the template
+// YAML) via a restricted enum of known methods.
+type HTTPMethodTypeHolder struct {
+	Method string
+}
+
+// String returns the underlying HTTP method.
+func (h HTTPMethodTypeHolder) String() string {
+	return h.Method
+}
+
+// SignatureTypeHolder holds the request-signing scheme (eg. "aws") selected
+// by a template's `signature:` field, if any.
+type SignatureTypeHolder struct {
+	Value string
+}
+
+// Request contains an HTTP protocol request to be made from a template.
+//
+// isRaw/GetVariablesDefault/GetVariablesNamesSkipList/LeaveDefaultPorts are
+// referenced by build_request.go but are pre-existing gaps in this snapshot
+// that predate (and are unrelated to) the HTTP2/BasicAuth/RetryPolicy fields
+// declared below; they are intentionally left alone here.
+type Request struct {
+	// Path is the list of HTTP paths/requests to send, templated with
+	// {{BaseURL}} and friends.
+	Path []string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Raw is the list of raw HTTP requests, used instead of Path when set.
+	Raw []string `yaml:"raw,omitempty" json:"raw,omitempty"`
+	// Method is the HTTP method used for Path-based requests.
+	Method HTTPMethodTypeHolder `yaml:"method,omitempty" json:"method,omitempty"`
+	// Headers is a map of headers to set on the request.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Body is the optional request body for Path-based requests.
+	Body string `yaml:"body,omitempty" json:"body,omitempty"`
+	// Race, if true, fires every request at the same approximated time.
+	Race bool `yaml:"race,omitempty" json:"race,omitempty"`
+	// Threads is the number of concurrent requests/streams to fire; <= 0
+	// also closes the underlying connection after each request.
+	Threads int `yaml:"threads,omitempty" json:"threads,omitempty"`
+	// Unsafe opts into rawhttp for requests that violate the net/http
+	// request model (eg. malformed request lines, duplicate headers).
+	Unsafe bool `yaml:"unsafe,omitempty" json:"unsafe,omitempty"`
+	// SelfContained marks a request that carries its own target instead of
+	// resolving one from the scan input.
+	SelfContained bool `yaml:"-" json:"-"`
+	// Signature selects a request-signing scheme applied before sending.
+	Signature SignatureTypeHolder `yaml:"signature,omitempty" json:"signature,omitempty"`
+
+	// BasicAuthUsername/BasicAuthPassword set HTTP Basic auth credentials
+	// for the request, taking precedence over credentials embedded in the
+	// target URL but yielding to an explicit Authorization header.
+	BasicAuthUsername string `yaml:"basic-auth-username,omitempty" json:"basic-auth-username,omitempty"`
+	BasicAuthPassword string `yaml:"basic-auth-password,omitempty" json:"basic-auth-password,omitempty"`
+	// DigestAuthUsername/DigestAuthPassword set HTTP Digest auth
+	// credentials for the request.
+	DigestAuthUsername string `yaml:"digest-auth-username,omitempty" json:"digest-auth-username,omitempty"`
+	DigestAuthPassword string `yaml:"digest-auth-password,omitempty" json:"digest-auth-password,omitempty"`
+
+	// HTTP2 opts the request into HTTP/2: a shared *http2.Transport for
+	// Path-based requests, or frame-level control via h2FrameRequest when
+	// combined with Unsafe on a raw request.
+	HTTP2 bool `yaml:"http2,omitempty" json:"http2,omitempty"`
+	// ContinuationSplit/Streams only apply to Unsafe && HTTP2 raw requests,
+	// see h2FrameRequest for their semantics.
+	ContinuationSplit int `yaml:"continuation-split,omitempty" json:"continuation-split,omitempty"`
+	Streams           int `yaml:"streams,omitempty" json:"streams,omitempty"`
+
+	// RetryPolicy overrides the client's globally-configured retry/backoff
+	// behavior for this request alone.
+	RetryPolicy *RetryPolicy `yaml:"retry-policy,omitempty" json:"retry-policy,omitempty"`
+
+	options *protocols.ExecutorOptions
+}
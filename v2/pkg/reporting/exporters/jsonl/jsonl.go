@@ -1,77 +1,209 @@
 package jsonl
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/json"
-	"github.com/pkg/errors"
-	"github.com/projectdiscovery/nuclei/v2/pkg/output"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
-)
+	"time"
 
-type Exporter struct {
-	options *Options
-	mutex   *sync.Mutex
-	rows    []output.ResultEvent
-}
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/nuclei/v2/pkg/output"
+)
 
 // Options contains the configuration options for JSONL exporter client
 type Options struct {
 	// File is the file to export found JSONL result to
 	File string `yaml:"file"`
+	// Append opens File with O_APPEND instead of truncating it, so
+	// re-running a scan against the same output file accumulates results.
+	Append bool `yaml:"append"`
+	// Gzip gzip-compresses the output stream; also implied by File ending
+	// in ".gz".
+	Gzip bool `yaml:"gzip"`
+	// MaxSizeMB rotates to a new file once the current one has written more
+	// than this many megabytes. Zero disables size-based rotation.
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxAgeHours rotates to a new file once the current one has been open
+	// longer than this many hours. Zero disables time-based rotation.
+	MaxAgeHours int `yaml:"max_age_hours"`
+}
+
+// Exporter is a JSONL exporter client. Results are streamed to disk one
+// line at a time as they're exported, rather than buffered in memory until
+// Close, so a large scan's result set can't OOM the process.
+type Exporter struct {
+	options *Options
+	mutex   *sync.Mutex
+
+	file       *os.File
+	gzipWriter *gzip.Writer
+	counter    *countingWriter
+	writer     *bufio.Writer
+	encoder    *json.Encoder
+	openedAt   time.Time
+	rotation   int
 }
 
 // New creates a new JSONL exporter integration client based on options.
 func New(options *Options) (*Exporter, error) {
-	exporter := &Exporter{
+	return &Exporter{
 		mutex:   &sync.Mutex{},
 		options: options,
-		rows:    []output.ResultEvent{},
-	}
-	return exporter, nil
+	}, nil
 }
 
-// Export appends the passed result event to the list of objects to be exported to
-// the resulting JSONL file
+// Export streams the passed result event as a single JSONL line, opening
+// (or rotating, per Options.MaxSizeMB/MaxAgeHours) the output file first if
+// needed.
 func (exporter *Exporter) Export(event *output.ResultEvent) error {
 	exporter.mutex.Lock()
 	defer exporter.mutex.Unlock()
 
-	// Add the event to the rows
-	exporter.rows = append(exporter.rows, *event)
+	if exporter.writer == nil {
+		if err := exporter.open(); err != nil {
+			return err
+		}
+	} else if exporter.shouldRotate() {
+		if err := exporter.rotate(); err != nil {
+			return err
+		}
+	}
 
+	if err := exporter.encoder.Encode(event); err != nil {
+		return errors.Wrap(err, "failed to encode JSONL line")
+	}
 	return nil
 }
 
-// Close writes the in-memory data to the JSONL file specified by options.JSONLExport
-// and closes the exporter after operation
+// Close flushes and closes the currently open JSONL file, if any.
 func (exporter *Exporter) Close() error {
 	exporter.mutex.Lock()
 	defer exporter.mutex.Unlock()
 
-	// Open the JSONL file for writing and create it if it doesn't exist
-	f, err := os.OpenFile(exporter.options.File, os.O_WRONLY|os.O_CREATE, 0644)
+	return exporter.closeCurrent()
+}
+
+// open creates (or appends to) the current rotation's file and sets up the
+// gzip/bufio/json.Encoder writer chain for it.
+func (exporter *Exporter) open() error {
+	path := exporter.currentPath()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if exporter.options.Append {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
 	if err != nil {
 		return errors.Wrap(err, "failed to create JSONL file")
 	}
 
-	// Loop through the rows and convert each to a JSON byte array and write to file
-	for _, row := range exporter.rows {
-		// Convert the row to JSON byte array
-		obj, err := json.Marshal(row)
-		if err != nil {
-			return errors.Wrap(err, "failed to generate row for JSONL report")
-		}
+	var writer io.Writer = file
+	if exporter.options.Gzip || strings.HasSuffix(path, ".gz") {
+		exporter.gzipWriter = gzip.NewWriter(file)
+		writer = exporter.gzipWriter
+	}
 
-		// Attempt to append the JSON line to file specified in options.JSONExport
-		if _, err = f.Write(obj); err != nil {
-			return errors.Wrap(err, "failed to append JSONL line")
-		}
+	exporter.file = file
+	exporter.counter = &countingWriter{writer: writer}
+	exporter.writer = bufio.NewWriter(exporter.counter)
+	exporter.encoder = json.NewEncoder(exporter.writer)
+	exporter.openedAt = time.Now()
+	return nil
+}
+
+// shouldRotate reports whether the current file has crossed a configured
+// size or age threshold.
+func (exporter *Exporter) shouldRotate() bool {
+	if exporter.options.MaxSizeMB > 0 && exporter.counter.written >= int64(exporter.options.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	if exporter.options.MaxAgeHours > 0 && time.Since(exporter.openedAt) >= time.Duration(exporter.options.MaxAgeHours)*time.Hour {
+		return true
+	}
+	return false
+}
+
+// rotate closes the current file and opens the next one in the rotation.
+func (exporter *Exporter) rotate() error {
+	if err := exporter.closeCurrent(); err != nil {
+		return err
+	}
+	exporter.rotation++
+	return exporter.open()
+}
+
+// closeCurrent flushes and closes whatever file is currently open, leaving
+// the exporter ready to open() again.
+func (exporter *Exporter) closeCurrent() error {
+	if exporter.writer == nil {
+		return nil
 	}
 
-	// Close the file
-	if err := f.Close(); err != nil {
+	if err := exporter.writer.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush JSONL writer")
+	}
+	if exporter.gzipWriter != nil {
+		if err := exporter.gzipWriter.Close(); err != nil {
+			return errors.Wrap(err, "failed to close gzip writer")
+		}
+		exporter.gzipWriter = nil
+	}
+	if err := exporter.file.Close(); err != nil {
 		return errors.Wrap(err, "failed to close JSONL file")
 	}
 
+	exporter.file = nil
+	exporter.counter = nil
+	exporter.writer = nil
+	exporter.encoder = nil
 	return nil
 }
+
+// currentPath returns the path for the current rotation: Options.File
+// itself for the first file, and a "file.N.ext" variant once rotation has
+// kicked in at least once.
+func (exporter *Exporter) currentPath() string {
+	if exporter.rotation == 0 {
+		return exporter.options.File
+	}
+	return rotatedFileName(exporter.options.File, exporter.rotation)
+}
+
+// rotatedFileName inserts ".N" before the file's extension, treating a
+// trailing ".gz" as part of the extension so eg. with idx 2 "results.jsonl"
+// becomes "results.2.jsonl" and "results.jsonl.gz" becomes
+// "results.2.jsonl.gz". The first rotated file uses idx 1.
+func rotatedFileName(path string, idx int) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	if ext == ".gz" {
+		innerExt := filepath.Ext(base)
+		base = strings.TrimSuffix(base, innerExt)
+		return base + "." + strconv.Itoa(idx) + innerExt + ext
+	}
+	return base + "." + strconv.Itoa(idx) + ext
+}
+
+// countingWriter tracks how many bytes have been written so far, so
+// Options.MaxSizeMB can be enforced without stat-ing the file on every
+// write (which would also undercount while a gzip.Writer is still buffering
+// unflushed data).
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,59 @@
+package stub
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// dnsHandler returns a dns.HandlerFunc backed by the server's configured
+// records/latency/truncation/nxdomain/tsig options.
+func (s *Server) dnsHandler() dns.HandlerFunc {
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		if s.dnsLatency > 0 {
+			time.Sleep(s.dnsLatency)
+		}
+
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+
+		if len(s.dnsTSIGKeys) > 0 {
+			if r.IsTsig() != nil {
+				status := w.TsigStatus()
+				if status == nil {
+					msg.SetTsig(r.Extra[len(r.Extra)-1].(*dns.TSIG).Hdr.Name, dns.HmacSHA256, 300, time.Now().Unix())
+				} else {
+					msg.SetRcode(r, dns.RcodeNotAuth)
+					_ = w.WriteMsg(msg)
+					return
+				}
+			}
+		}
+
+		if s.dnsNXDOMAIN {
+			msg.SetRcode(r, dns.RcodeNameError)
+			_ = w.WriteMsg(msg)
+			return
+		}
+
+		if len(r.Question) > 0 {
+			question := r.Question[0]
+			msg.Answer = append(msg.Answer, s.dnsRecords[question.Name]...)
+		}
+		msg.Truncated = s.dnsTruncated
+
+		_ = w.WriteMsg(msg)
+	}
+}
+
+// httpHandler serves a minimal configurable HTTP responder used by functional
+// tests that need an upstream HTTP target alongside the DNS stub.
+func (s *Server) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	return mux
+}
@@ -0,0 +1,49 @@
+package stub
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Option configures a Server before it is started.
+type Option func(*Server)
+
+// WithDNSRecords seeds the DNS stub with a fixed set of records, keyed by the
+// fully qualified question name (eg. "example.com.").
+func WithDNSRecords(records map[string][]dns.RR) Option {
+	return func(s *Server) {
+		s.dnsRecords = records
+	}
+}
+
+// WithLatency adds an artificial delay before the DNS stub answers a query,
+// useful for exercising timeout/retry behaviour in the resolver.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.dnsLatency = latency
+	}
+}
+
+// WithTruncated makes the DNS stub respond with the truncated (TC) bit set,
+// forcing callers to retry over TCP.
+func WithTruncated() Option {
+	return func(s *Server) {
+		s.dnsTruncated = true
+	}
+}
+
+// WithNXDOMAIN makes the DNS stub answer every query with NXDOMAIN.
+func WithNXDOMAIN() Option {
+	return func(s *Server) {
+		s.dnsNXDOMAIN = true
+	}
+}
+
+// WithTSIG enables TSIG verification on the DNS stub using the given key
+// name/secret pair (as accepted by miekg/dns, eg. "axfr.":"base64secret").
+func WithTSIG(key map[string]string) Option {
+	return func(s *Server) {
+		s.dnsTSIGKeys = key
+	}
+}
@@ -0,0 +1,86 @@
+// Package stub provides in-process DNS and HTTP servers for functional
+// testing of nuclei protocol executors, modelled on gitlab-pages's
+// `gitlabstub` test server split into server.go/handlers.go/option.go.
+package stub
+
+import (
+	"net"
+	"net/http/httptest"
+	"time"
+
+	"github.com/miekg/dns"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// Server is an in-process DNS + HTTP stub used by functional tests to
+// exercise protocol executors end-to-end without a real upstream.
+type Server struct {
+	DNSAddr  string
+	HTTPAddr string
+
+	dnsServer  *dns.Server
+	httpServer *httptest.Server
+
+	dnsRecords   map[string][]dns.RR
+	dnsLatency   time.Duration
+	dnsTruncated bool
+	dnsNXDOMAIN  bool
+	dnsTSIGKeys  map[string]string
+}
+
+// New creates and starts a new stub server with the given options applied.
+func New(opts ...Option) (*Server, error) {
+	s := &Server{dnsRecords: make(map[string][]dns.RR)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.startDNS(); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not start dns stub")
+	}
+	s.startHTTP()
+	return s, nil
+}
+
+// startDNS binds a UDP listener and starts serving DNS queries in the background.
+func (s *Server) startDNS() error {
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", s.dnsHandler())
+
+	server := &dns.Server{PacketConn: packetConn, Handler: mux}
+	if len(s.dnsTSIGKeys) > 0 {
+		server.TsigSecret = s.dnsTSIGKeys
+	}
+
+	ready := make(chan error, 1)
+	server.NotifyStartedFunc = func() { ready <- nil }
+	go func() {
+		if serveErr := server.ActivateAndServe(); serveErr != nil {
+			select {
+			case ready <- serveErr:
+			default:
+			}
+		}
+	}()
+
+	s.dnsServer = server
+	s.DNSAddr = packetConn.LocalAddr().String()
+	return nil
+}
+
+// startHTTP starts an in-process HTTP test server.
+func (s *Server) startHTTP() {
+	s.httpServer = httptest.NewServer(s.httpHandler())
+	s.HTTPAddr = s.httpServer.Listener.Addr().String()
+}
+
+// Close shuts down both the DNS and HTTP stub servers.
+func (s *Server) Close() error {
+	s.httpServer.Close()
+	return s.dnsServer.Shutdown()
+}